@@ -0,0 +1,40 @@
+package remotelist
+
+import "context"
+
+// NewAsync is like NewWithOptions but returns immediately and loads the list in the background. Until then,
+// the RemoteList has no records, e.g. Has returns `false`. The returned channel receives the result of the
+// load (nil if it succeeded) and is closed afterwards. If the options are invalid, the RemoteList is `nil`
+// and the channel receives that error. Use Ready or WaitReady to wait for the records.
+func NewAsync(fileLocal, fileRemote string, opts ...Option) (*RemoteList, <-chan error) {
+	errs := make(chan error, 1)
+	rl, err := newRemoteList(fileLocal, fileRemote, opts...)
+	if err != nil {
+		errs <- err
+		close(errs)
+		return nil, errs
+	}
+
+	go func() {
+		errs <- rl.start(context.Background())
+		close(errs)
+	}()
+	return rl, errs
+}
+
+// Ready returns a channel that is closed once records have been loaded for the first time,
+// e.g. by the background load of NewAsync or by a Refresh after a failed start.
+func (rl *RemoteList) Ready() <-chan struct{} {
+	return rl.ready
+}
+
+// WaitReady blocks until records have been loaded for the first time or `ctx` is canceled.
+// A failed load doesn't end the wait, use LastError to check for it.
+func (rl *RemoteList) WaitReady(ctx context.Context) error {
+	select {
+	case <-rl.ready:
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
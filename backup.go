@@ -0,0 +1,136 @@
+package remotelist
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// backupFile returns the path of the backup `generation` of `fileLocal`, 1 being the newest
+func backupFile(fileLocal string, generation int) string {
+	return fmt.Sprintf("%s.%d", fileLocal, generation)
+}
+
+// copyFile copies the file `from` to `to` in `storage` with the permissions `perm`, or those of `from` if `perm` is 0
+func copyFile(storage Storage, from, to string, perm os.FileMode) error {
+	fileInfo, err := storage.Stat(from)
+	if err != nil {
+		return err
+	}
+	src, err := storage.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	if perm == 0 {
+		perm = fileInfo.Mode().Perm()
+	}
+	return storage.Write(to, perm, func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// renamer is implemented by Storages that can rename files, which replaces the file `to` if it exists
+type renamer interface {
+	Rename(from, to string) error
+}
+
+// renameFile renames the file `from` to `to` in `storage`, replacing `to`. If `storage` can't rename
+// files, `from` is copied to `to` and removed.
+func renameFile(storage Storage, from, to string) error {
+	if r, ok := storage.(renamer); ok {
+		return r.Rename(from, to)
+	}
+	if err := copyFile(storage, from, to, 0); err != nil {
+		return err
+	}
+	return storage.Remove(from)
+}
+
+// rotateBackups shifts the backups by one generation, dropping the oldest, and copies the local file to the newest.
+// The backups are renamed, only the local file is copied, so it stays in place until the new version replaces it.
+func (rl *RemoteList) rotateBackups() error {
+	if rl.backups == 0 {
+		return nil
+	}
+	for i := rl.backups; i > 1; i-- {
+		err := renameFile(rl.storage, backupFile(rl.fileLocal, i-1), backupFile(rl.fileLocal, i))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("could not rotate backups: %w", err)
+		}
+	}
+	err := copyFile(rl.storage, rl.fileLocal, backupFile(rl.fileLocal, 1), rl.fileMode)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("could not rotate backups: %w", err)
+	}
+	return nil
+}
+
+// Rollback restores the newest backup kept with WithBackups and reloads the records from it.
+// The restored backup is removed from the backups, so calling Rollback again restores the next older one.
+// It fails with ErrNoBackup if there is none left. The restored file counts as new, so it is not
+// downloaded again before maxAge has passed. Stats reports the generation that is loaded.
+// A download that is in progress is finished first, refreshes wait until the backup has been restored.
+func (rl *RemoteList) Rollback() error {
+	if rl.isClosed() {
+		return ErrClosed
+	}
+
+	// Hold loadMu once no load is in progress, so no download can start while the files are shifted
+	rl.loadMu.Lock()
+	for rl.flight != nil {
+		c := rl.flight
+		rl.loadMu.Unlock()
+		<-c.done
+		rl.loadMu.Lock()
+	}
+	defer rl.loadMu.Unlock()
+
+	// Other processes using the local file must not download it while it is restored
+	unlock, err := rl.lockLocal(rl.closing)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	err = renameFile(rl.storage, backupFile(rl.fileLocal, 1), rl.fileLocal)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNoBackup
+	}
+	if err != nil {
+		return fmt.Errorf("%w, could not restore backup: %w", ErrWriteLocal, err)
+	}
+	if err := rl.storage.Touch(rl.fileLocal, time.Now()); err != nil {
+		return fmt.Errorf("%w, could not restore backup: %w", ErrWriteLocal, err)
+	}
+
+	// Shift the remaining backups up by one generation
+	for i := 1; i < max(rl.backups, 1); i++ {
+		err := renameFile(rl.storage, backupFile(rl.fileLocal, i+1), backupFile(rl.fileLocal, i))
+		if errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w, could not shift backups: %w", ErrWriteLocal, err)
+		}
+	}
+
+	if err := rl.init(); err != nil {
+		return err
+	}
+	rl.mu.Lock()
+	rl.generation++
+	rl.stale = false
+	rl.lastErr = nil
+	generation := rl.generation
+	rl.mu.Unlock()
+	rl.log(slog.LevelInfo, "list rolled back", "generation", generation)
+	return nil
+}
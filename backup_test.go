@@ -0,0 +1,100 @@
+package remotelist
+
+import (
+	"errors"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRollback(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"file", nil},
+		{"file lock", []Option{WithFileLock(time.Second)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newTestRemote(t, "v1.com\n")
+			rl := newTestList(t, remote.URL, append([]Option{WithBackups(2)}, tt.opts...)...)
+			for _, v := range []string{"v2.com\n", "v3.com\n", "v4.com\n"} {
+				remote.set(v)
+				if err := rl.Refresh(true); err != nil {
+					t.Fatalf("Refresh: %v", err)
+				}
+			}
+
+			// Only two backups are kept, v1 has been dropped
+			for _, want := range []string{"v3.com", "v2.com"} {
+				if err := rl.Rollback(); err != nil {
+					t.Fatalf("Rollback: %v", err)
+				}
+				if got := rl.List(); !slices.Equal(got, []string{want}) {
+					t.Errorf("List() after Rollback = %q, want [%s]", got, want)
+				}
+			}
+			if err := rl.Rollback(); !errors.Is(err, ErrNoBackup) {
+				t.Errorf("Rollback without backups: got %v, want ErrNoBackup", err)
+			}
+			if g := rl.Stats().Generation; g != 2 {
+				t.Errorf("Generation = %d, want 2", g)
+			}
+
+			// The restored file counts as new
+			if err := rl.Refresh(false); err != nil || !rl.Has("v2.com") {
+				t.Errorf("Refresh after Rollback: %v, records %q", err, rl.List())
+			}
+		})
+	}
+}
+
+func TestBackupsWithoutLocalFile(t *testing.T) {
+	// Lists kept in memory only don't keep their content, so there is nothing to back up
+	remote := newTestRemote(t, "v1.com\n")
+	if _, err := NewWithOptions("", remote.URL, WithBackups(2)); err == nil {
+		t.Error("WithBackups was accepted for a list without local file")
+	}
+}
+
+func TestRollbackDuringRefresh(t *testing.T) {
+	remote := newTestRemote(t, "v1.com\n")
+	rl := newTestList(t, remote.URL, WithBackups(3), WithMaxAge(RefreshAlways))
+	remote.set("v2.com\n")
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	done := make(chan error)
+	go func() {
+		for range 20 {
+			if err := rl.Refresh(true); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	for range 20 {
+		if err := rl.Rollback(); err != nil && !errors.Is(err, ErrNoBackup) {
+			t.Errorf("Rollback: %v", err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Refresh: %v", err)
+	}
+
+	// Whatever the order, the local file and the records are a complete version of the list
+	data, err := os.ReadFile(rl.fileLocal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := string(data); s != "v1.com\n" && s != "v2.com\n" {
+		t.Errorf("local file = %q", s)
+	}
+	if rl.Len() != 1 {
+		t.Errorf("List() = %q, want a single version", rl.List())
+	}
+}
@@ -0,0 +1,72 @@
+package remotelist
+
+import (
+	"hash/maphash"
+	"math"
+)
+
+// bloom is a Bloom filter over strings. It answers "definitely not present" or "maybe present",
+// so lookups of missing values can skip the records. Strings can be added but not removed.
+type bloom struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    uint64 // number of hash functions
+	seed maphash.Seed
+}
+
+// newBloom creates a Bloom filter sized for `n` strings with the false-positive rate `rate`
+func newBloom(n int, rate float64) *bloom {
+	n = max(n, 1)
+	m := uint64(math.Ceil(-float64(n) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	m = max(m, 64)
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	k = max(k, 1)
+	return &bloom{
+		bits: make([]uint64, (m+63)/64),
+		m:    m,
+		k:    k,
+		seed: maphash.MakeSeed(),
+	}
+}
+
+// positions calls `fn` with the bit positions of `s` until it returns `false`, using double hashing
+func (b *bloom) positions(s string, fn func(pos uint64) bool) {
+	h := maphash.String(b.seed, s)
+	h1, h2 := h&math.MaxUint32, h>>32|1
+	for i := uint64(0); i < b.k; i++ {
+		if !fn((h1 + i*h2) % b.m) {
+			return
+		}
+	}
+}
+
+// add adds `s` to the filter
+func (b *bloom) add(s string) {
+	b.positions(s, func(pos uint64) bool {
+		b.bits[pos/64] |= 1 << (pos % 64)
+		return true
+	})
+}
+
+// has reports whether `s` may have been added. If it returns `false`, `s` has definitely not been added.
+func (b *bloom) has(s string) bool {
+	found := true
+	b.positions(s, func(pos uint64) bool {
+		found = b.bits[pos/64]&(1<<(pos%64)) != 0
+		return found
+	})
+	return found
+}
+
+// newBloomIndex creates a Bloom filter of the case-folded `records` if enabled with WithBloomFilter,
+// otherwise it returns `nil`
+func (rl *RemoteList) newBloomIndex(records map[string]struct{}) *bloom {
+	if rl.bloomRate == 0 {
+		return nil
+	}
+	b := newBloom(len(records), rl.bloomRate)
+	for rec := range records {
+		b.add(rl.fold(rec))
+	}
+	return b
+}
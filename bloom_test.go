@@ -0,0 +1,63 @@
+package remotelist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBloomFilter(t *testing.T) {
+	domains := testDomains(10_000)
+	b := newBloom(len(domains), 0.01)
+	for _, d := range domains {
+		b.add(d)
+	}
+	for _, d := range domains {
+		if !b.has(d) {
+			t.Fatalf("has(%q) = false for an added string", d)
+		}
+	}
+	falsePositives := 0
+	for i := range 10_000 {
+		if b.has(fmt.Sprintf("missing%d.example.org", i)) {
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / 10_000; rate > 0.02 {
+		t.Errorf("false-positive rate = %.3f, want about 0.01", rate)
+	}
+
+	// Records added later pass the filter, so do removed ones until the next reload
+	rl, err := NewFromStrings(domains[:100], WithBloomFilter(0.01), WithFastHas())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rl.Add("Added.com")
+	rl.Remove(domains[0])
+	if !rl.Has("added.com") || rl.Has(domains[0]) {
+		t.Errorf("Has() is wrong after Add and Remove")
+	}
+}
+
+func BenchmarkBloomFilterMiss(b *testing.B) {
+	for _, rate := range []float64{0, 0.01, 0.001} {
+		b.Run(fmt.Sprintf("rate=%v", rate), func(b *testing.B) {
+			var opts []Option
+			if rate > 0 {
+				opts = append(opts, WithBloomFilter(rate))
+			}
+			// With the default HasFunc every miss scans the records, unless the filter rejects it
+			rl := newBenchList(b, 100_000, opts...)
+			misses := make([]string, 1024)
+			for i := range misses {
+				misses[i] = fmt.Sprintf("missing%d.example.org", i)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rl.Has(misses[i%len(misses)])
+			}
+			if rl.bloom != nil {
+				b.ReportMetric(float64(len(rl.bloom.bits)*8), "filter-bytes")
+			}
+		})
+	}
+}
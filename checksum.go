@@ -0,0 +1,75 @@
+package remotelist
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// parseChecksum parses a hex-encoded SHA-256 checksum. It accepts the output of `sha256sum`,
+// i.e. the checksum may be followed by a filename.
+func parseChecksum(s string) ([]byte, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("empty checksum")
+	}
+	sum, err := hex.DecodeString(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum: %s", err.Error())
+	}
+	if len(sum) != sha256.Size {
+		return nil, fmt.Errorf("invalid checksum: expected %d bytes, got %d", sha256.Size, len(sum))
+	}
+	return sum, nil
+}
+
+// fetchChecksum downloads the expected checksum from the configured checksum URL
+func (rl *RemoteList) fetchChecksum(ctx context.Context) ([]byte, error) {
+	req, err := rl.newRequest(ctx, rl.checksumURL)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+
+	// A checksum file holds a single line, anything beyond that is not a checksum file
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return nil, err
+	}
+	return parseChecksum(string(data))
+}
+
+// expectedChecksum returns the expected checksum of the download, fetching it from the checksum URL
+// if one is configured. It returns `nil` if no checksum is configured.
+func (rl *RemoteList) expectedChecksum(ctx context.Context) ([]byte, error) {
+	if rl.checksumURL == "" {
+		return rl.checksum, nil
+	}
+	sum, err := rl.fetchChecksum(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w, could not get checksum: %w", ErrDownloadFailed, err)
+	}
+	return sum, nil
+}
+
+// verifyChecksum compares the checksum `sum` of the downloaded content with the `expected` one
+func verifyChecksum(expected, sum []byte) error {
+	if expected != nil && !bytes.Equal(sum, expected) {
+		return fmt.Errorf("%w: expected %x, got %x", ErrChecksumMismatch, expected, sum)
+	}
+	return nil
+}
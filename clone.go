@@ -0,0 +1,67 @@
+package remotelist
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Clone returns an independent copy of the RemoteList, e.g. to try changes with Add and Remove and compare
+// the result with the original (see Difference). The copy has the same configuration and records but its own
+// locks, records and indexes, so changes to one don't affect the other. It is kept in memory and never loaded
+// again: it has no remote location and Refresh does nothing. Records added with AddWithTTL keep their expiry.
+func (rl *RemoteList) Clone() *RemoteList {
+	// loadMu guards the load that is in progress, mu the rest of the state. A lazy list is loaded
+	// first, loading takes loadMu itself.
+	rl.lazyInit()
+	rl.loadMu.Lock()
+	defer rl.loadMu.Unlock()
+	rl.rlock()
+	defer rl.mu.RUnlock()
+
+	c := *rl
+	c.mu, c.loadMu, c.initMu = &sync.RWMutex{}, &sync.Mutex{}, &sync.Mutex{}
+	c.flight, c.lazy = nil, nil
+	c.cancel, c.done = nil, nil
+	c.watchInterval, c.watchCancel, c.watchDone = 0, nil, nil
+	c.closed = false
+	c.closing, c.closeLoads = context.WithCancel(context.Background())
+	c.ready = make(chan struct{})
+	if rl.loaded {
+		close(c.ready)
+	}
+	c.static, c.ephemeral, c.ephemeralDir = true, false, ""
+	c.fileLocal, c.fileRemote, c.mirrors, c.overrides = "", "", nil, nil
+	c.memoryOnly, c.storage = true, NewMemoryStorage()
+	c.published, c.edits, c.copied = &atomic.Pointer[recordSet]{}, nil, false
+	c.changes, c.served = &atomic.Uint64{}, &atomic.Pointer[servedList]{}
+
+	records := map[string]struct{}{}
+	rl.eachRecord(func(rec string) bool {
+		records[rec] = struct{}{}
+		return true
+	})
+	added := map[string]struct{}{}
+	for _, rec := range rl.addedRecords() {
+		added[rec] = struct{}{}
+	}
+	c.records, c.added, c.sorted, c.shards = records, added, nil, nil
+	switch {
+	case rl.shards != nil:
+		c.records, c.added, c.shards = nil, nil, c.newShards(c.shardCount, records, added)
+	case rl.sortRecords:
+		c.records, c.sorted = nil, slices.Clone(rl.sorted)
+	}
+	c.prefixes = c.newIndex(c.indexPrefix, records, false)
+	c.suffixes = c.newIndex(c.indexSuffix, records, true)
+	c.networks = c.newIPIndex(records)
+	c.bloom = c.newBloomIndex(records)
+
+	c.expiries, c.expiryTimer = maps.Clone(rl.expiries), nil
+	if len(c.expiries) > 0 {
+		c.scheduleExpiry()
+	}
+	return &c
+}
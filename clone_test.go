@@ -0,0 +1,71 @@
+package remotelist
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"map", []Option{WithPrefixIndex()}},
+		{"sorted", []Option{WithSortedStorage(), WithFastHas()}},
+		{"sharded", []Option{WithShards(4), WithFastHas()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newTestRemote(t, "a.com\nb.com\n")
+			rl := newTestList(t, remote.URL, tt.opts...)
+			rl.Add("manual.com")
+			rl.AddWithTTL("temporary.com", time.Hour)
+
+			c := rl.Clone()
+			defer c.Close()
+			want := []string{"a.com", "b.com", "manual.com", "temporary.com"}
+			if got := c.List(); !slices.Equal(got, want) {
+				t.Fatalf("clone: List() = %q, want %q", got, want)
+			}
+
+			// Changes to one don't affect the other
+			c.Add("clone.com")
+			c.Remove("a.com")
+			rl.Add("original.com")
+			rl.Remove("b.com")
+			if got, want := rl.List(), []string{"a.com", "manual.com", "original.com", "temporary.com"}; !slices.Equal(got, want) {
+				t.Errorf("original: List() = %q, want %q", got, want)
+			}
+			if got, want := c.List(), []string{"b.com", "clone.com", "manual.com", "temporary.com"}; !slices.Equal(got, want) {
+				t.Errorf("clone: List() = %q, want %q", got, want)
+			}
+			if !c.HasPrefix("clone") || c.HasPrefix("original") {
+				t.Error("the indexes of the clone are shared with the original")
+			}
+
+			// The clone is never loaded again
+			remote.set("c.com\n")
+			if err := rl.Refresh(true); err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+			hits := remote.hits.Load()
+			if err := c.Refresh(true); err != nil {
+				t.Fatalf("clone: Refresh: %v", err)
+			}
+			if n := remote.hits.Load(); n != hits {
+				t.Errorf("clone: Refresh downloaded the list")
+			}
+			if c.Has("c.com") || !c.Has("b.com") || !rl.Has("c.com") {
+				t.Errorf("got records %q for the original and %q for the clone", rl.List(), c.List())
+			}
+
+			// Closing the original leaves the clone usable
+			rl.Close()
+			c.Add("after.com")
+			if !c.Has("after.com") {
+				t.Error("clone: Add after closing the original did nothing")
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package remotelist
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Close ends the life of the RemoteList: it stops the auto-refresh goroutine, the watcher of the local file
+// and the expiry timer, aborts a download that is in progress and waits for it to finish. Afterwards Refresh
+// and Save fail with ErrClosed (as does EnsureLoaded if the list hasn't been loaded), while StartAutoRefresh
+// and the methods that change the records (Add, AddWithTTL, Remove, AddAll, RemoveAll and Clear) do nothing.
+// The records that have been loaded can still be used. The ephemeral cache of a list created
+// with WithEphemeralCache is removed, Close returns the error if that fails. Calling Close again does nothing.
+// It implements io.Closer.
+func (rl *RemoteList) Close() error {
+	rl.mu.Lock()
+	if rl.closed {
+		rl.mu.Unlock()
+		return nil
+	}
+	rl.closed = true
+	if rl.expiryTimer != nil {
+		rl.expiryTimer.Stop()
+	}
+	rl.mu.Unlock()
+
+	rl.closeLoads()
+	rl.Stop()
+	rl.loadMu.Lock()
+	c := rl.flight
+	rl.loadMu.Unlock()
+	if c != nil {
+		<-c.done
+	}
+	return rl.removeEphemeral()
+}
+
+// removeEphemeral removes the ephemeral cache (if any) together with all sidecar files of the local file
+func (rl *RemoteList) removeEphemeral() error {
+	if rl.ephemeralDir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(rl.ephemeralDir); err != nil {
+		return fmt.Errorf("%w, could not remove ephemeral cache: %w", ErrWriteLocal, err)
+	}
+	return nil
+}
+
+// isClosed reports whether Close has been called
+func (rl *RemoteList) isClosed() bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.closed
+}
+
+// closeable returns a context that is canceled with ErrClosed when `ctx` is canceled or the RemoteList is closed
+func (rl *RemoteList) closeable(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	stop := context.AfterFunc(rl.closing, func() { cancel(ErrClosed) })
+	return ctx, func() {
+		stop()
+		cancel(nil)
+	}
+}
@@ -0,0 +1,50 @@
+package remotelist
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestChangesAfterClose(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"map", nil},
+		{"sharded", []Option{WithShards(4), WithFastHas()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newTestRemote(t, "a.com\nb.com\n")
+			rl := newTestList(t, remote.URL, tt.opts...)
+			rl.AddWithTTL("temporary.com", time.Hour)
+			if err := rl.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			rl.Add("c.com")
+			rl.AddWithTTL("d.com", time.Millisecond)
+			if rl.Remove("a.com") {
+				t.Error("Remove removed a record")
+			}
+			if n := rl.AddAll([]string{"e.com"}); n != 0 {
+				t.Errorf("AddAll added %d records", n)
+			}
+			if n := rl.RemoveAll([]string{"b.com"}); n != 0 {
+				t.Errorf("RemoveAll removed %d records", n)
+			}
+			rl.Clear()
+			rl.StartAutoRefresh(time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+
+			// The loaded records can still be used
+			if got, want := rl.List(), []string{"a.com", "b.com", "temporary.com"}; !slices.Equal(got, want) {
+				t.Errorf("List() = %q, want %q", got, want)
+			}
+			if n := remote.hits.Load(); n != 1 {
+				t.Errorf("got %d requests, want 1", n)
+			}
+		})
+	}
+}
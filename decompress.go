@@ -0,0 +1,66 @@
+package remotelist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+)
+
+// A `Decompressor` decompresses downloaded lists of a specific compression format.
+//
+// Implement this interface and add it with WithDecompressors to support formats other than gzip, e.g. zstd.
+type Decompressor interface {
+	// Detect reports whether the downloaded content is compressed with this format.
+	// It receives the response of the download and the first bytes (up to 512) of the still compressed content.
+	// The response is `nil` when reading the local file or when the list was retrieved by a Fetcher.
+	Detect(resp *http.Response, head []byte) bool
+
+	// Decompress returns a reader that decompresses `r`.
+	Decompress(r io.Reader) (io.ReadCloser, error)
+}
+
+// gzipDecompressor decompresses gzip-compressed content
+type gzipDecompressor struct{}
+
+// gzipMagic is the header every gzip stream starts with
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// Detect checks for the gzip magic bytes. Headers and file extensions are not reliable
+// because some servers decompress `.gz` files on the fly.
+func (gzipDecompressor) Detect(resp *http.Response, head []byte) bool {
+	return bytes.HasPrefix(head, gzipMagic)
+}
+
+func (gzipDecompressor) Decompress(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// GzipDecompressor decompresses gzip-compressed lists. It is enabled by default.
+var GzipDecompressor Decompressor = gzipDecompressor{}
+
+// decompressReader returns a reader that decompresses `r` with the first of the `decompressors` that detects
+// its format based on `resp` and `head`, which holds the first bytes of `r`.
+// If none does, `r` is returned as-is.
+func decompressReader(decompressors []Decompressor, resp *http.Response, head []byte, r io.Reader) (io.ReadCloser, error) {
+	for _, d := range decompressors {
+		if d.Detect(resp, head) {
+			return d.Decompress(r)
+		}
+	}
+	return io.NopCloser(r), nil
+}
+
+// isGzipFile reports whether the file at `path` in `storage` starts with the gzip magic bytes
+func isGzipFile(storage Storage, path string) bool {
+	f, err := storage.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	header := make([]byte, len(gzipMagic))
+	if _, err := io.ReadFull(f, header); err != nil {
+		return false
+	}
+	return bytes.Equal(header, gzipMagic)
+}
@@ -0,0 +1,40 @@
+package remotelist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGzipDownload(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("a.com\nb.com\n"))
+	zw.Close()
+
+	tests := []struct {
+		name   string
+		header http.Header
+	}{
+		{"plain", http.Header{"Content-Type": {"application/octet-stream"}}},
+		{"gzip content type", http.Header{"Content-Type": {"application/gzip"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tt.header {
+					w.Header()[k] = v
+				}
+				w.Write(buf.Bytes())
+			}))
+			defer srv.Close()
+
+			rl := newTestList(t, srv.URL+"/list.txt.gz")
+			if rl.Len() != 2 || !rl.Has("a.com") || !rl.Has("b.com") {
+				t.Errorf("List() = %q, want [a.com b.com]", rl.List())
+			}
+		})
+	}
+}
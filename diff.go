@@ -0,0 +1,21 @@
+package remotelist
+
+import "sort"
+
+// diffRecords returns the sorted records that are only in `current` (added) and only in `previous` (removed)
+func diffRecords(previous, current map[string]struct{}) (added, removed []string) {
+	added, removed = []string{}, []string{}
+	for rec := range current {
+		if _, ok := previous[rec]; !ok {
+			added = append(added, rec)
+		}
+	}
+	for rec := range previous {
+		if _, ok := current[rec]; !ok {
+			removed = append(removed, rec)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,27 @@
+package remotelist
+
+import "strings"
+
+// HasDomain checks if `host` or any of its parent domains is a record of the RemoteList,
+// e.g. "a.b.example.com" matches the records "a.b.example.com", "b.example.com" and "example.com",
+// but unlike HasSuffix "notexample.com" doesn't match "example.com". Each candidate is a single map lookup.
+// The host is normalized and compared like in Has, i.e. case-folded with WithUnicodeFolding and taken as it is
+// with WithCaseSensitive, and stripped of a trailing dot. Records are expected in the same form (see WithDomains).
+func (rl *RemoteList) HasDomain(host string) bool {
+	host = strings.TrimSuffix(rl.fold(rl.normalize(host)), ".")
+	if host == "" {
+		return false
+	}
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	for {
+		if rl.hasRecord(host) {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+}
@@ -0,0 +1,39 @@
+package remotelist
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestHasDomain(t *testing.T) {
+	records := []string{"example.com", "straße.example", "Mixed.Example"}
+	tests := []struct {
+		name string
+		opts []Option
+		host string
+		want bool
+	}{
+		{"exact", []Option{WithDomains()}, "example.com", true},
+		{"subdomain", []Option{WithDomains()}, "a.b.example.com", true},
+		{"trailing dot and case", []Option{WithDomains()}, " A.Example.COM. ", true},
+		{"not a parent domain", []Option{WithDomains()}, "notexample.com", false},
+		{"empty", []Option{WithDomains()}, "", false},
+		{"sharp s", []Option{WithDomains(), WithUnicodeFolding(language.Und)}, "www.STRASSE.example", true},
+		{"sharp s in the query", []Option{WithDomains(), WithUnicodeFolding(language.Und)}, "www.Straße.example", true},
+		{"case-sensitive", []Option{WithCaseSensitive()}, "www.Mixed.Example", true},
+		{"case-sensitive mismatch", []Option{WithCaseSensitive()}, "www.mixed.example", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromStrings(records, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rl.Close()
+			if got := rl.HasDomain(tt.host); got != tt.want {
+				t.Errorf("HasDomain(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package remotelist
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrDownloadFailed is returned when the list could not be downloaded, e.g. because of a network error
+	// or a bad status code. It wraps the underlying error.
+	ErrDownloadFailed = errors.New("list download failed")
+
+	// ErrBadStatus is returned when the remote responds with a status code other than 200 OK.
+	// Use errors.As with a *StatusError to get the status code.
+	ErrBadStatus = errors.New("list download failed with bad status")
+
+	// ErrDownloadTimeout is returned when a download takes longer than the timeout set with WithDownloadTimeout.
+	ErrDownloadTimeout = errors.New("list download timed out")
+
+	// ErrDownloadTooLarge is returned when a download exceeds the size set with WithMaxDownloadSize.
+	ErrDownloadTooLarge = errors.New("list download too large")
+
+	// ErrChecksumMismatch is returned when the downloaded content does not match the expected SHA-256 checksum.
+	ErrChecksumMismatch = errors.New("list checksum mismatch")
+
+	// ErrWriteLocal is returned when the local file could not be written. It wraps the underlying error.
+	ErrWriteLocal = errors.New("could not write local file")
+
+	// ErrReadLocal is returned when the local file could not be read. It wraps the underlying error.
+	ErrReadLocal = errors.New("could not read local file")
+
+	// ErrValidation is returned when a downloaded list is rejected by a validator (see WithValidator).
+	// It wraps the error of the validator.
+	ErrValidation = errors.New("list validation failed")
+
+	// ErrLockTimeout is returned when the lock of the local file could not be acquired within the timeout
+	// set with WithFileLock, e.g. because another process is stuck while downloading the list.
+	ErrLockTimeout = errors.New("timed out waiting for the lock of the local file")
+
+	// ErrClosed is returned by methods that load or write the list once the RemoteList has been closed.
+	ErrClosed = errors.New("list is closed")
+
+	// ErrNoBackup is returned by Rollback when there is no backup to restore.
+	ErrNoBackup = errors.New("no backup of the local file")
+)
+
+// StatusError is returned when the remote responds with a status code other than 200 OK.
+// It matches ErrBadStatus and ErrDownloadFailed with errors.Is.
+type StatusError struct {
+	StatusCode int           // HTTP status code of the response
+	RetryAfter time.Duration // Delay advised by the Retry-After header of a 429 or 503 response, 0 if there was none
+}
+
+func (e *StatusError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("list download failed with status code: %d, retry after %s", e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("list download failed with status code: %d", e.StatusCode)
+}
+
+// Is reports whether `target` is ErrBadStatus or ErrDownloadFailed
+func (e *StatusError) Is(target error) bool {
+	return target == ErrBadStatus || target == ErrDownloadFailed
+}
@@ -0,0 +1,63 @@
+package remotelist
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+func TestErrors(t *testing.T) {
+	forbidden := newTestRemote(t, "")
+	forbidden.status.Store(http.StatusForbidden)
+	closed := newTestRemote(t, "")
+	closed.Close()
+	ok := newTestRemote(t, "a.com\n")
+
+	// A file where a directory is expected can't be written to
+	blocked := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("network", func(t *testing.T) {
+		_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), closed.URL)
+		if !errors.Is(err, ErrDownloadFailed) || errors.Is(err, ErrBadStatus) {
+			t.Errorf("got %v, want ErrDownloadFailed", err)
+		}
+		var errURL *url.Error
+		if !errors.As(err, &errURL) {
+			t.Errorf("got %v, want a *url.Error", err)
+		}
+	})
+	t.Run("status", func(t *testing.T) {
+		_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), forbidden.URL)
+		if !errors.Is(err, ErrDownloadFailed) || !errors.Is(err, ErrBadStatus) {
+			t.Errorf("got %v, want ErrDownloadFailed and ErrBadStatus", err)
+		}
+		var errStatus *StatusError
+		if !errors.As(err, &errStatus) || errStatus.StatusCode != http.StatusForbidden {
+			t.Errorf("got %v, want a *StatusError with status code 403", err)
+		}
+	})
+	t.Run("write local", func(t *testing.T) {
+		_, err := NewWithOptions(filepath.Join(blocked, "list.txt"), ok.URL)
+		if !errors.Is(err, ErrWriteLocal) || errors.Is(err, ErrDownloadFailed) {
+			t.Errorf("got %v, want ErrWriteLocal", err)
+		}
+		var errPath *os.PathError
+		if !errors.As(err, &errPath) {
+			t.Errorf("got %v, want a *os.PathError", err)
+		}
+	})
+	t.Run("read local", func(t *testing.T) {
+		errRead := errors.New("read failed")
+		_, err := NewFromReader(iotest.ErrReader(errRead))
+		if !errors.Is(err, ErrReadLocal) || !errors.Is(err, errRead) {
+			t.Errorf("got %v, want ErrReadLocal wrapping %v", err, errRead)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package remotelist_test
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/toxyl/remotelist"
+)
+
+func ExampleRemoteList_All() {
+	rl, err := remotelist.NewFromStrings([]string{"a.com", "b.com", "c.com"})
+	if err != nil {
+		panic(err)
+	}
+	defer rl.Close()
+
+	for rec := range rl.All() {
+		fmt.Println(rec)
+	}
+	// Unordered output:
+	// a.com
+	// b.com
+	// c.com
+}
+
+func ExampleWithStreamFilter() {
+	data := "# ads\nads.example.com\n\n// trackers\ntracker.example.com\n"
+	rl, err := remotelist.NewFromReader(strings.NewReader(data), remotelist.WithStreamFilter(remotelist.StripCommentsStreamFilter))
+	if err != nil {
+		panic(err)
+	}
+	defer rl.Close()
+
+	fmt.Println(rl.List())
+	// Output: [ads.example.com tracker.example.com]
+}
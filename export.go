@@ -0,0 +1,50 @@
+package remotelist
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"sort"
+)
+
+// Export writes all records, sorted and each followed by `sep`, to `w` and returns the number of bytes written.
+// The records are collected under the read lock, which is released before anything is written, so a slow
+// writer doesn't hold up changes to the list. This costs a slice of all records (the strings are shared).
+func (rl *RemoteList) Export(w io.Writer, sep string) (int64, error) {
+	rl.rlock()
+	var records []string
+	if rl.sortRecords {
+		records = slices.Clone(rl.sorted)
+	} else {
+		records = make([]string, 0, rl.recordCount())
+		rl.eachRecord(func(rec string) bool {
+			records = append(records, rec)
+			return true
+		})
+	}
+	rl.mu.RUnlock()
+	if !rl.sortRecords {
+		sort.Strings(records)
+	}
+
+	bw := bufio.NewWriter(w)
+	n := int64(0)
+	for _, rec := range records {
+		m, err := bw.WriteString(rec)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+		m, err = bw.WriteString(sep)
+		n += int64(m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, bw.Flush()
+}
+
+// WriteTo writes all records to `w`, sorted and one per line. It implements io.WriterTo.
+func (rl *RemoteList) WriteTo(w io.Writer) (int64, error) {
+	return rl.Export(w, "\n")
+}
@@ -0,0 +1,96 @@
+package remotelist
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExport(t *testing.T) {
+	values := []string{"c.com", "a.com", "d.com", "b.com"}
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"map", nil},
+		{"sorted", []Option{WithSortedStorage(), WithFastHas()}},
+		{"sharded", []Option{WithShards(4), WithFastHas()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromStrings(values, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rl.Add("e.com")
+
+			var buf bytes.Buffer
+			n, err := rl.Export(&buf, ",")
+			if err != nil {
+				t.Fatalf("Export: %v", err)
+			}
+			want := strings.Join(rl.List(), ",") + ","
+			if buf.String() != want || n != int64(len(want)) {
+				t.Errorf("Export() wrote %q (%d bytes), want %q", buf.String(), n, want)
+			}
+
+			buf.Reset()
+			if _, err := rl.WriteTo(&buf); err != nil {
+				t.Fatalf("WriteTo: %v", err)
+			}
+			if want := strings.Join(rl.List(), "\n") + "\n"; buf.String() != want {
+				t.Errorf("WriteTo() wrote %q, want %q", buf.String(), want)
+			}
+		})
+	}
+}
+
+// blockingWriter blocks every write until `release` is closed
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (bw *blockingWriter) Write(p []byte) (int, error) {
+	bw.once.Do(func() { close(bw.started) })
+	<-bw.release
+	return len(p), nil
+}
+
+func TestExportSlowWriter(t *testing.T) {
+	rl, err := NewFromStrings(testDomains(1000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+
+	w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		_, err := rl.Export(w, "\n")
+		done <- err
+	}()
+	<-w.started
+
+	// A writer waiting for the lock must not hold up the readers behind it
+	added := make(chan struct{})
+	go func() {
+		rl.Add("new.com")
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Error("Add is blocked by a slow Export")
+	}
+	close(w.release)
+	if err := <-done; err != nil {
+		t.Errorf("Export: %v", err)
+	}
+	if !rl.Has("new.com") {
+		t.Error("Has(new.com) = false")
+	}
+}
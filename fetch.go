@@ -0,0 +1,81 @@
+package remotelist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// A `Fetcher` retrieves lists from remote locations other than HTTP(S), e.g. S3 buckets or SFTP servers.
+//
+// Implement this interface and set it with WithFetcher to plug in other sources. The retrieved content is
+// decompressed, filtered, verified and written to the local file just like an HTTP download.
+type Fetcher interface {
+	// Fetch returns the content of the list at `url`. The caller closes it.
+	Fetch(ctx context.Context, url string) (io.ReadCloser, error)
+}
+
+// HTTPFetcher is a Fetcher that downloads lists with a plain GET request, e.g. to wrap it in another Fetcher.
+// Without a Fetcher, the RemoteList downloads with its own HTTP client, which also supports the configured
+// headers, the request modifier and skipping unmodified lists.
+type HTTPFetcher struct {
+	Client *http.Client // The client to use, DefaultHTTPClient if nil
+}
+
+// Fetch downloads `url` and fails with a *StatusError if the server doesn't respond with 200 OK.
+func (f HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	client := f.Client
+	if client == nil {
+		client = DefaultHTTPClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode}
+	}
+	return resp.Body, nil
+}
+
+// fileFetcher is the Fetcher used for `file://` URLs and absolute paths
+type fileFetcher struct{}
+
+// Fetch opens the file `url` refers to and fails with a *StatusError with status code 404 if it doesn't exist,
+// just like an HTTP download of a missing list.
+func (fileFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	path, _ := localSource(url)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, &StatusError{StatusCode: http.StatusNotFound}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// localSource returns the path of the local file `remote` refers to if it is a `file://` URL or an absolute path
+func localSource(remote string) (string, bool) {
+	if u, err := url.Parse(remote); err == nil && u.Scheme == "file" {
+		if u.Host != "" && u.Host != "localhost" {
+			return "", false
+		}
+		return filepath.FromSlash(u.Path), true
+	}
+	if filepath.IsAbs(remote) {
+		return remote, true
+	}
+	return "", false
+}
@@ -0,0 +1,124 @@
+package remotelist
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// mapFetcher serves lists from memory, locations that aren't in the map fail
+type mapFetcher struct {
+	mu    sync.Mutex
+	lists map[string]string
+	urls  []string
+}
+
+func (f *mapFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.urls = append(f.urls, url)
+	list, ok := f.lists[url]
+	if !ok {
+		return nil, errors.New("no such object")
+	}
+	return io.NopCloser(strings.NewReader(list)), nil
+}
+
+func TestWithFetcher(t *testing.T) {
+	f := &mapFetcher{lists: map[string]string{"s3://backup/list.txt": "# comment\na.com\nb.com\n"}}
+	local := filepath.Join(t.TempDir(), "list.txt")
+
+	// Mirrors, filters and the local file work the same as for HTTP downloads
+	rl, err := NewWithOptions(local, "s3://primary/list.txt",
+		WithFetcher(f),
+		WithMirrors("s3://backup/list.txt"),
+		WithStreamFilter(StripCommentsStreamFilter),
+	)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	if !rl.Has("a.com") || rl.Len() != 2 || rl.Source() != "s3://backup/list.txt" {
+		t.Errorf("got records %q from %q", rl.List(), rl.Source())
+	}
+	data, err := os.ReadFile(local)
+	if err != nil || string(data) != "a.com\nb.com\n" {
+		t.Errorf("local file = %q, %v", data, err)
+	}
+
+	// A fresh local file isn't fetched again
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if len(f.urls) != 2 {
+		t.Errorf("fetched %q, want the primary location and the mirror once", f.urls)
+	}
+
+	// Errors of the Fetcher are download errors
+	_, err = NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), "s3://missing/list.txt", WithFetcher(f))
+	if !errors.Is(err, ErrDownloadFailed) {
+		t.Errorf("NewWithOptions: got %v, want ErrDownloadFailed", err)
+	}
+}
+
+func TestHTTPFetcher(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	rl := newTestList(t, remote.URL, WithFetcher(HTTPFetcher{}))
+	if !rl.Has("a.com") {
+		t.Errorf("List() = %q, want [a.com]", rl.List())
+	}
+
+	remote.status.Store(http.StatusNotFound)
+	_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), remote.URL, WithFetcher(HTTPFetcher{}))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("NewWithOptions: got %v, want a 404 StatusError", err)
+	}
+}
+
+func TestFileSource(t *testing.T) {
+	dir := t.TempDir()
+	source := filepath.Join(dir, "share", "list.txt")
+	if err := os.MkdirAll(filepath.Dir(source), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, remote := range map[string]string{"URL": "file://" + filepath.ToSlash(source), "path": source} {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(source, []byte("# mounted\na.com\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			local := filepath.Join(t.TempDir(), "list.txt")
+			rl, err := NewWithOptions(local, remote, WithStreamFilter(StripCommentsStreamFilter), WithMaxAge(RefreshAlways))
+			if err != nil {
+				t.Fatalf("NewWithOptions: %v", err)
+			}
+			defer rl.Close()
+			data, err := os.ReadFile(local)
+			if !rl.Has("a.com") || err != nil || string(data) != "a.com\n" {
+				t.Fatalf("got records %q and local file %q (%v)", rl.List(), data, err)
+			}
+
+			// Changes to the source are copied on the next refresh
+			if err := os.WriteFile(source, []byte("b.com\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := rl.Refresh(false); err != nil || !rl.Has("b.com") || rl.Has("a.com") {
+				t.Errorf("Refresh: %v, records %q", err, rl.List())
+			}
+		})
+	}
+
+	// A missing source file fails like a missing HTTP list
+	_, err := NewWithOptions(filepath.Join(dir, "list.txt"), "file://"+filepath.ToSlash(filepath.Join(dir, "missing.txt")))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		t.Errorf("NewWithOptions: got %v, want a 404 StatusError", err)
+	}
+}
@@ -0,0 +1,238 @@
+package remotelist
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// errReader remembers the first error (other than io.EOF) returned by the underlying reader.
+// This allows to tell read errors apart from write errors after an io.Copy.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+func (er *errReader) Read(p []byte) (int, error) {
+	n, err := er.r.Read(p)
+	if err != nil && err != io.EOF && er.err == nil {
+		er.err = err
+	}
+	return n, err
+}
+
+// limitedReader fails with ErrDownloadTooLarge once more than `max` bytes have been read
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	read int64
+}
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	n, err := lr.r.Read(p)
+	lr.read += int64(n)
+	if lr.read > lr.max {
+		return n, fmt.Errorf("%w: exceeds the limit of %d bytes", ErrDownloadTooLarge, lr.max)
+	}
+	return n, err
+}
+
+// limitReader limits `r` to `max` bytes, a `max` of 0 or less means no limit
+func limitReader(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &limitedReader{r: r, max: max}
+}
+
+// createDir creates the missing parent directories of the local file, unless disabled with WithoutCreateDirs.
+// Other storages than the local file system have no directories to create.
+func (rl *RemoteList) createDir() error {
+	if _, ok := rl.storage.(FileStorage); !ok || rl.dirMode == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(rl.fileLocal), rl.dirMode); err != nil {
+		return fmt.Errorf("%w, could not create directory: %w", ErrWriteLocal, err)
+	}
+	return nil
+}
+
+// cachePath returns the path of the local file for `fileRemote` in the user's cache directory. The file name
+// consists of the host and the last path element of `fileRemote`, reduced to characters that are valid
+// on all platforms, and a hash of the complete `fileRemote`, so URLs that only differ e.g. in the query don't collide.
+func cachePath(fileRemote string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("%w, could not determine cache directory: %w", ErrWriteLocal, err)
+	}
+
+	name := fileRemote
+	if u, err := url.Parse(fileRemote); err == nil {
+		name = u.Host + "_" + path.Base(u.Path)
+	}
+	name = strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return '_'
+	}, name)
+	name = strings.Trim(name, "._")
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	sum := sha256.Sum256([]byte(fileRemote))
+	return filepath.Join(dir, "remotelist", name+"-"+hex.EncodeToString(sum[:8])), nil
+}
+
+// localMode returns the permissions for writing the local file and its sidecar files: those set with WithFileMode,
+// otherwise those of the existing local file or 0644 if there is none
+func (rl *RemoteList) localMode() os.FileMode {
+	if rl.fileMode != 0 {
+		return rl.fileMode
+	}
+	if fileInfo, err := rl.storage.Stat(rl.fileLocal); err == nil {
+		return fileInfo.Mode().Perm()
+	}
+	return 0644
+}
+
+// writeFile writes the content produced by `fn` to a temporary file in the directory of `path`
+// and atomically replaces `path` with it once `fn` succeeded and the content has been flushed to disk.
+// If anything fails, `path` is left untouched.
+func writeFile(path string, permissions os.FileMode, fn func(w io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly once the file has been renamed
+
+	if err := fn(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(permissions); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes the directory entry of a renamed file to disk.
+// This is best effort, not all platforms support syncing directories.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
+
+// localFile is a local file opened for reading, decompressed if it was stored compressed
+type localFile struct {
+	io.Reader
+	f io.ReadCloser
+	z *gzip.Reader
+}
+
+func (lf *localFile) Close() error {
+	if lf.z != nil {
+		lf.z.Close()
+	}
+	return lf.f.Close()
+}
+
+// openLocal opens the local file at `path` in `storage` for streaming. Files starting with the gzip magic bytes
+// are decompressed and a leading UTF-8 byte order mark is skipped.
+func openLocal(storage Storage, path string) (io.ReadCloser, error) {
+	f, err := storage.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return readLocal(f)
+}
+
+// readLocal prepares `f` for streaming like openLocal, e.g. a download that is parsed without being stored
+func readLocal(f io.ReadCloser) (io.ReadCloser, error) {
+	var err error
+	lf := &localFile{f: f}
+	br := bufio.NewReader(f)
+	if head, _ := br.Peek(len(gzipMagic)); bytes.Equal(head, gzipMagic) {
+		if lf.z, err = gzip.NewReader(br); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("could not decompress: %w", err)
+		}
+		br = bufio.NewReader(lf.z)
+	}
+	if head, _ := br.Peek(len(utf8BOM)); bytes.Equal(head, utf8BOM) {
+		_, _ = br.Discard(len(utf8BOM))
+	}
+	lf.Reader = br
+	return lf, nil
+}
+
+// progressReader reports the number of bytes read to a ProgressFunc every `progressBytes` bytes
+// or `progressInterval`, whichever comes first, and once the underlying reader is exhausted
+type progressReader struct {
+	r        io.Reader
+	fn       ProgressFunc
+	read     int64
+	total    int64
+	reported int64
+	last     time.Time
+}
+
+const (
+	progressBytes    = 256 * 1024
+	progressInterval = time.Second
+)
+
+func (pr *progressReader) Read(p []byte) (int, error) {
+	n, err := pr.r.Read(p)
+	pr.read += int64(n)
+	if err == io.EOF || pr.read-pr.reported >= progressBytes || (n > 0 && time.Since(pr.last) >= progressInterval) {
+		if pr.read != pr.reported || err == io.EOF {
+			pr.fn(pr.read, pr.total)
+		}
+		pr.reported, pr.last = pr.read, time.Now()
+	}
+	return n, err
+}
+
+// progress wraps `r` so the configured ProgressFunc (if any) is called while it is read.
+// `read` is the number of bytes that have already been read elsewhere, `total` the expected size or -1.
+func (rl *RemoteList) progress(r io.Reader, read, total int64) io.Reader {
+	if rl.fnProgress == nil {
+		return r
+	}
+	if total < 0 {
+		total = -1
+	}
+	fn := func(bytesRead, totalBytes int64) {
+		rl.callback(func() { rl.fnProgress(bytesRead, totalBytes) })
+	}
+	return &progressReader{r: r, fn: fn, read: read, total: total, reported: read, last: time.Now()}
+}
@@ -0,0 +1,92 @@
+package remotelist
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWriteFileFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "list.txt")
+	if err := os.WriteFile(path, []byte("a.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	errWrite := errors.New("disk full")
+	err := writeFile(path, 0644, func(w io.Writer) error {
+		io.WriteString(w, "b.com\n")
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("writeFile: got %v, want %v", err, errWrite)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "a.com\n" {
+		t.Errorf("file content = %q, want %q", data, "a.com\n")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+func TestTruncatedDownloadKeepsFile(t *testing.T) {
+	var truncate atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !truncate.Load() {
+			io.WriteString(w, "a.com\n")
+			return
+		}
+		// Announce more than is sent, the connection breaks halfway through the body
+		w.Header().Set("Content-Length", "100")
+		io.WriteString(w, "b.com\n")
+	}))
+	defer srv.Close()
+
+	rl := newTestList(t, srv.URL)
+	truncate.Store(true)
+	// The RemoteList falls back to the previous file, the download error is only remembered
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rl.LastError() == nil {
+		t.Error("LastError() = nil after a truncated download")
+	}
+	if data, _ := os.ReadFile(rl.fileLocal); string(data) != "a.com\n" {
+		t.Errorf("file content = %q, want %q", data, "a.com\n")
+	}
+	if !rl.Has("a.com") || rl.Has("b.com") {
+		t.Errorf("List() = %q, want [a.com]", rl.List())
+	}
+}
+
+func TestCreateDirs(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	local := filepath.Join(t.TempDir(), "one", "two", "three", "list.txt")
+
+	rl, err := NewWithOptions(local, remote.URL)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	if _, err := os.Stat(local); err != nil || !rl.Has("a.com") {
+		t.Errorf("local file three levels deep: %v, records %q", err, rl.List())
+	}
+
+	// Without creating directories, or if a directory can't be created, writing fails
+	missing := filepath.Join(t.TempDir(), "missing", "list.txt")
+	if _, err := NewWithOptions(missing, remote.URL, WithoutCreateDirs()); !errors.Is(err, ErrWriteLocal) {
+		t.Errorf("WithoutCreateDirs: got %v, want ErrWriteLocal", err)
+	}
+	blocked := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocked, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewWithOptions(filepath.Join(blocked, "dir", "list.txt"), remote.URL); !errors.Is(err, ErrWriteLocal) {
+		t.Errorf("directory below a file: got %v, want ErrWriteLocal", err)
+	}
+}
@@ -0,0 +1,75 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package remotelist
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestWithFileMode(t *testing.T) {
+	// The modes are set explicitly, so a restrictive umask doesn't change them
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	remote := newTestRemote(t, "a.com\n")
+	local := filepath.Join(t.TempDir(), "list.txt")
+	if err := os.WriteFile(local, []byte("old.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rl, err := NewWithOptions(local, remote.URL, WithFileMode(0640), WithBackups(1), WithMaxAge(RefreshAlways))
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	rl.Add("b.com")
+	if err := rl.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	for _, file := range []string{local, metadataFile(local), backupFile(local, 1), addedFile(local)} {
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			t.Errorf("%s: %v", filepath.Base(file), err)
+			continue
+		}
+		if mode := fileInfo.Mode().Perm(); mode != 0640 {
+			t.Errorf("%s has mode %s, want %s", filepath.Base(file), mode, os.FileMode(0640))
+		}
+	}
+}
+
+func TestDefaultFileMode(t *testing.T) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	remote := newTestRemote(t, "a.com\n")
+	dir := t.TempDir()
+
+	// New files get 0644, existing files keep their mode when they are replaced
+	fresh := newTestList(t, remote.URL)
+	existing := filepath.Join(dir, "existing.txt")
+	if err := os.WriteFile(existing, []byte("old.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rl, err := NewWithOptions(existing, remote.URL, WithMaxAge(RefreshAlways))
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+
+	for file, want := range map[string]os.FileMode{fresh.fileLocal: 0644, existing: 0600} {
+		fileInfo, err := os.Stat(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mode := fileInfo.Mode().Perm(); mode != want {
+			t.Errorf("%s has mode %s, want %s", filepath.Base(file), mode, want)
+		}
+	}
+	if !rl.Has("a.com") {
+		t.Errorf("existing file was not replaced: %q", rl.List())
+	}
+}
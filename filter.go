@@ -0,0 +1,72 @@
+package remotelist
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// The `StripCommentsStreamFilter` function drops empty lines and lines starting with `#` or `//`
+// from the downloaded content. It copies the lines through a fixed buffer, so its memory use doesn't
+// depend on the size of the content or the length of the lines.
+var StripCommentsStreamFilter StreamFilterFunc = func(dst io.Writer, src io.Reader) error {
+	r := bufio.NewReader(src)
+	w := bufio.NewWriter(dst)
+	indent := []byte{}
+	for {
+		// Only the leading whitespace has to be held back until we know whether the line is kept
+		indent = indent[:0]
+		for {
+			c, err := r.ReadByte()
+			if err == io.EOF {
+				return w.Flush()
+			}
+			if err != nil {
+				return err
+			}
+			if c != ' ' && c != '\t' && c != '\r' && c != '\v' && c != '\f' {
+				_ = r.UnreadByte()
+				break
+			}
+			indent = append(indent, c)
+		}
+
+		head, _ := r.Peek(2)
+		keep := head[0] != '\n' && head[0] != '#' && !bytes.HasPrefix(head, []byte("//"))
+		if keep {
+			if _, err := w.Write(indent); err != nil {
+				return err
+			}
+		}
+		for {
+			chunk, err := r.ReadSlice('\n')
+			if keep {
+				if _, err := w.Write(chunk); err != nil {
+					return err
+				}
+			}
+			if err == bufio.ErrBufferFull {
+				continue
+			}
+			if err == io.EOF {
+				return w.Flush()
+			}
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+}
+
+// dataFilterStream adapts the DataFilterFunc `fn` to a StreamFilterFunc. The adapter reads the complete content into memory.
+func dataFilterStream(fn DataFilterFunc) StreamFilterFunc {
+	return func(dst io.Writer, src io.Reader) error {
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(dst, fn(string(data)))
+		return err
+	}
+}
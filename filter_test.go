@@ -0,0 +1,73 @@
+package remotelist
+
+import (
+	"bytes"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestStripCommentsStreamFilter(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"comments", "# header\na.com\n// note\nb.com\n", "a.com\nb.com\n"},
+		{"indented", "  # header\n\ta.com\n  // note\n", "\ta.com\n"},
+		{"empty lines", "\n  \n\r\na.com\r\n\n", "a.com\r\n"},
+		{"no final line ending", "a.com\n# b.com", "a.com\n"},
+		{"long lines", strings.Repeat("a", 10000) + "\n#" + strings.Repeat("b", 10000) + "\nc.com", strings.Repeat("a", 10000) + "\nc.com"},
+		{"slash", "/path\n", "/path\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := StripCommentsStreamFilter(&buf, strings.NewReader(tt.data)); err != nil {
+				t.Fatalf("StripCommentsStreamFilter: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// repeatReader yields `line` `n` times without holding the content in memory
+type repeatReader struct {
+	line []byte
+	n    int
+	off  int
+}
+
+func (r *repeatReader) Read(p []byte) (int, error) {
+	read := 0
+	for read < len(p) && r.n > 0 {
+		c := copy(p[read:], r.line[r.off:])
+		read += c
+		r.off += c
+		if r.off == len(r.line) {
+			r.off = 0
+			r.n--
+		}
+	}
+	if read == 0 {
+		return 0, io.EOF
+	}
+	return read, nil
+}
+
+func TestStripCommentsStreamFilterMemory(t *testing.T) {
+	// 64 MiB of content must pass through without being held in memory
+	src := &repeatReader{line: []byte("# comment line\nhost.example.com\n"), n: 2 << 20}
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	if err := StripCommentsStreamFilter(io.Discard, src); err != nil {
+		t.Fatalf("StripCommentsStreamFilter: %v", err)
+	}
+	runtime.ReadMemStats(&after)
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("filtering 64 MiB allocated %d bytes", allocated)
+	}
+}
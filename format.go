@@ -0,0 +1,194 @@
+package remotelist
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"iter"
+	"strings"
+	"unicode"
+)
+
+// utf8BOM is the byte order mark some tools put at the start of UTF-8 files
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// A splitFunc splits the content of a local file into raw records, which are then passed through the DataLineFunc.
+// Read errors are yielded as errors and end the iteration. Entries that can't be parsed are skipped
+// and counted in `malformed`.
+type splitFunc func(r io.Reader, malformed *int) iter.Seq2[string, error]
+
+// splitLines returns a splitFunc that splits the content into lines with either LF or CRLF line endings, this is the default.
+// Lines longer than `maxLength` bytes fail with bufio.ErrTooLong.
+func splitLines(maxLength int) splitFunc {
+	return func(r io.Reader, malformed *int) iter.Seq2[string, error] {
+		return func(yield func(string, error) bool) {
+			scanner := bufio.NewScanner(r)
+			scanner.Buffer(make([]byte, 0, min(maxLength, 64*1024)), maxLength)
+			for scanner.Scan() {
+				if !yield(scanner.Text(), nil) {
+					return
+				}
+			}
+			if err := scanner.Err(); err != nil {
+				yield("", err)
+			}
+		}
+	}
+}
+
+// splitCSV returns a splitFunc that parses the content as CSV and yields the value of the
+// zero-based `column` of each row, skipping the first row if `hasHeader` is `true`.
+// Rows that are malformed or don't have enough columns are skipped.
+func splitCSV(column int, hasHeader bool, comma rune) splitFunc {
+	return func(r io.Reader, malformed *int) iter.Seq2[string, error] {
+		return func(yield func(string, error) bool) {
+			cr := csv.NewReader(r)
+			cr.Comma = comma
+			cr.FieldsPerRecord = -1
+			cr.ReuseRecord = true
+			header := hasHeader
+			for {
+				row, err := cr.Read()
+				if err == io.EOF {
+					return
+				}
+				var errParse *csv.ParseError
+				if errors.As(err, &errParse) {
+					*malformed++
+					continue
+				}
+				if err != nil {
+					yield("", err)
+					return
+				}
+				if header {
+					header = false
+					continue
+				}
+				if column >= len(row) {
+					*malformed++
+					continue
+				}
+				if !yield(row[column], nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// splitJSON returns a splitFunc that decodes the content either as a JSON array or as a stream of
+// JSON values (JSON lines) and yields the value at `fieldPath` of each element. The path is a list of
+// object keys separated by dots (e.g. "data.host"), an empty path expects the elements to be strings.
+// Elements without the field or with a value that is neither a string nor a number are skipped.
+func splitJSON(fieldPath string) splitFunc {
+	var path []string
+	if fieldPath != "" {
+		path = strings.Split(fieldPath, ".")
+	}
+	return func(r io.Reader, malformed *int) iter.Seq2[string, error] {
+		return func(yield func(string, error) bool) {
+			br := bufio.NewReader(r)
+			dec := json.NewDecoder(br)
+			dec.UseNumber()
+
+			// A leading bracket means the elements are wrapped in an array, otherwise they are JSON lines
+			array := false
+			for {
+				c, _, err := br.ReadRune()
+				if err == io.EOF {
+					return
+				}
+				if err != nil {
+					yield("", err)
+					return
+				}
+				if !unicode.IsSpace(c) {
+					array = c == '['
+					_ = br.UnreadRune()
+					break
+				}
+			}
+			if array {
+				if _, err := dec.Token(); err != nil {
+					yield("", err)
+					return
+				}
+			}
+
+			for !array || dec.More() {
+				var element any
+				err := dec.Decode(&element)
+				if !array && err == io.EOF {
+					return
+				}
+				if err != nil {
+					yield("", err)
+					return
+				}
+				value, ok := jsonField(element, path)
+				if !ok {
+					*malformed++
+					continue
+				}
+				if !yield(value, nil) {
+					return
+				}
+			}
+			if _, err := dec.Token(); err != nil {
+				yield("", err)
+			}
+		}
+	}
+}
+
+// jsonField returns the string representation of the value at `path` in the decoded JSON `element`
+func jsonField(element any, path []string) (string, bool) {
+	for _, key := range path {
+		obj, ok := element.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if element, ok = obj[key]; !ok {
+			return "", false
+		}
+	}
+	switch v := element.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// commentLineFunc returns a DataLineFunc that strips inline comments starting with `inline` (if not empty),
+// trims the line and excludes it if it's empty or starts with any of the `prefixes` (`#` and `//` if nil).
+// The remaining lines are passed to `next` if it's not nil.
+func commentLineFunc(prefixes []string, inline string, next DataLineFunc) DataLineFunc {
+	if prefixes == nil {
+		prefixes = []string{"#", "//"}
+	}
+	return func(line string) (string, bool) {
+		if inline != "" {
+			if i := strings.Index(line, inline); i >= 0 {
+				line = line[:i]
+			}
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			return "", false
+		}
+		for _, prefix := range prefixes {
+			if prefix != "" && strings.HasPrefix(line, prefix) {
+				return "", false
+			}
+		}
+		if next != nil {
+			return next(line)
+		}
+		return line, true
+	}
+}
@@ -0,0 +1,83 @@
+package remotelist
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestWithJSON(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		fieldPath string
+		want      []string
+		malformed int
+	}{
+		{
+			name: "array of strings",
+			data: `["a.com", "b.com",
+				"c.com"]`,
+			want: []string{"a.com", "b.com", "c.com"},
+		},
+		{
+			name:      "array of objects",
+			data:      `[{"data": {"host": "a.com"}}, {"data": {"host": "b.com", "port": 80}}, {"data": {}}, {"host": "c.com"}]`,
+			fieldPath: "data.host",
+			want:      []string{"a.com", "b.com"},
+			malformed: 2,
+		},
+		{
+			name:      "JSON lines",
+			data:      "{\"host\": \"a.com\"}\n{\"host\": \"b.com\"}\n\n{\"name\": \"c.com\"}\n",
+			fieldPath: "host",
+			want:      []string{"a.com", "b.com"},
+			malformed: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromReader(strings.NewReader(tt.data), WithJSON(tt.fieldPath))
+			if err != nil {
+				t.Fatalf("NewFromReader: %v", err)
+			}
+			defer rl.Close()
+			if got := rl.List(); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+			if got := rl.Stats().MalformedEntries; got != tt.malformed {
+				t.Errorf("MalformedEntries = %d, want %d", got, tt.malformed)
+			}
+		})
+	}
+}
+
+func TestLineEndings(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"LF", "a.com\nb.com\nc.com\n"},
+		{"CRLF", "a.com\r\nb.com\r\nc.com\r\n"},
+		{"mixed", "a.com\r\nb.com\nc.com\r\n"},
+		{"no final line ending", "a.com\r\nb.com\r\nc.com"},
+		{"BOM", "\ufeffa.com\nb.com\nc.com\n"},
+		{"BOM and CRLF", "\ufeffa.com\r\nb.com\r\nc.com"},
+	}
+	want := []string{"a.com", "b.com", "c.com"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromReader(strings.NewReader(tt.data))
+			if err != nil {
+				t.Fatalf("NewFromReader: %v", err)
+			}
+			defer rl.Close()
+			if got := rl.List(); !slices.Equal(got, want) {
+				t.Errorf("List() = %q, want %q", got, want)
+			}
+			if !rl.Has("a.com") || !rl.Has("c.com") {
+				t.Errorf("Has() failed for the first or last record")
+			}
+		})
+	}
+}
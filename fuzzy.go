@@ -0,0 +1,86 @@
+package remotelist
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// A `DistanceFunc` is used by SearchFuzzy to compute the edit distance between `record` and `term`.
+// It returns `ok = false` if the distance exceeds `max`, so implementations can stop early.
+type DistanceFunc func(record, term string, max int) (distance int, ok bool)
+
+// The default `Distance` function computes the Levenshtein distance (insertions, deletions and substitutions
+// of runes). It gives up as soon as the distance is known to exceed `max`.
+var DefaultDistanceFunc = func(record, term string, max int) (distance int, ok bool) {
+	a, b := []rune(record), []rune(term)
+	if diff := len(a) - len(b); diff > max || -diff > max {
+		return 0, false
+	}
+
+	// Only keep the previous and the current row of the distance matrix
+	prev, cur := make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > max {
+			return 0, false
+		}
+		prev, cur = cur, prev
+	}
+	if prev[len(b)] > max {
+		return 0, false
+	}
+	return prev[len(b)], true
+}
+
+// SearchFuzzy returns the records within an edit distance of `maxDistance` from `term`, e.g. "google.com" for
+// "gogle.com" and a distance of 1. The results are sorted by distance, then alphabetically. Records whose length
+// differs by more than `maxDistance` are skipped without comparing them. Matching is case-insensitive unless
+// WithCaseSensitive is used. The distance is computed by the DistanceFunc (see WithDistanceFunc).
+func (rl *RemoteList) SearchFuzzy(term string, maxDistance int) []string {
+	if maxDistance < 0 {
+		return []string{}
+	}
+	term = rl.fold(rl.query(term))
+	length := utf8.RuneCountInString(term)
+
+	type hit struct {
+		record   string
+		distance int
+	}
+	hits := []hit{}
+	rl.rlock()
+	rl.eachRecord(func(rec string) bool {
+		if diff := utf8.RuneCountInString(rec) - length; diff > maxDistance || -diff > maxDistance {
+			return true
+		}
+		if d, ok := rl.fnDistance(rl.fold(rec), term, maxDistance); ok {
+			hits = append(hits, hit{rec, d})
+		}
+		return true
+	})
+	rl.mu.RUnlock()
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].distance != hits[j].distance {
+			return hits[i].distance < hits[j].distance
+		}
+		return hits[i].record < hits[j].record
+	})
+	res := make([]string, len(hits))
+	for i, h := range hits {
+		res[i] = h.record
+	}
+	return res
+}
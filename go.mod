@@ -1,3 +1,8 @@
 module github.com/toxyl/remotelist
 
-go 1.22
+go 1.23
+
+require (
+	golang.org/x/net v0.33.0
+	golang.org/x/text v0.21.0
+)
@@ -0,0 +1,141 @@
+package remotelist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Handler returns an http.Handler that serves the records as text/plain, sorted and one per line,
+// so other processes can use this RemoteList as their remote location (e.g. a caching mirror).
+// The response has an ETag derived from its content, requests with a matching If-None-Match get 304 Not Modified.
+// It is gzip-compressed if the client accepts it. Only GET and HEAD requests are allowed.
+// The response is built once per change of the records and shared by all requests until the next one.
+func (rl *RemoteList) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		served, err := rl.serve()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		// The compressed representation needs its own ETag
+		gzipped := acceptsGzip(r)
+		etag := served.etag
+		if gzipped {
+			etag += "-gzip"
+		}
+		etag = `"` + etag + `"`
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Vary", "Accept-Encoding")
+		if matchesETag(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		content := served.body
+		if gzipped {
+			if content, err = served.compressed(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+		http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(content))
+	})
+}
+
+// servedList is the response of Handler for the records after a number of changes
+type servedList struct {
+	changes  uint64 // Number of changes of the records it has been built for
+	body     []byte
+	etag     string // ETag of the uncompressed body, without quotes
+	gzipOnce sync.Once
+	gzipped  []byte // Compressed body, built by the first request that accepts gzip
+	gzipErr  error
+}
+
+// serve returns the response of Handler for the current records. It is only built if the records have
+// changed since the last one, requests at the same time may build it more than once.
+func (rl *RemoteList) serve() (*servedList, error) {
+	// A lazy list is loaded first, its records change then
+	rl.lazyInit()
+	changes := rl.changes.Load()
+	if served := rl.served.Load(); served != nil && served.changes == changes {
+		return served, nil
+	}
+	// Changes from here on make the next request build it again
+	body := &bytes.Buffer{}
+	if _, err := rl.WriteTo(body); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(body.Bytes())
+	served := &servedList{changes: changes, body: body.Bytes(), etag: hex.EncodeToString(sum[:16])}
+	rl.served.Store(served)
+	return served, nil
+}
+
+// compressed returns the gzip-compressed body, compressing it on first use
+func (s *servedList) compressed() ([]byte, error) {
+	s.gzipOnce.Do(func() {
+		compressed := &bytes.Buffer{}
+		gz := gzip.NewWriter(compressed)
+		if _, s.gzipErr = gz.Write(s.body); s.gzipErr != nil {
+			return
+		}
+		if s.gzipErr = gz.Close(); s.gzipErr == nil {
+			s.gzipped = compressed.Bytes()
+		}
+	})
+	return s.gzipped, s.gzipErr
+}
+
+// Handler returns an http.Handler that serves each list of the Manager like RemoteList.Handler
+// under its name, e.g. `/ads` for the list added as "ads". Unknown names get 404 Not Found.
+// Use http.StripPrefix to mount it below a path.
+func (m *Manager) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rl, ok := m.Get(strings.TrimPrefix(r.URL.Path, "/"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		rl.Handler().ServeHTTP(w, r)
+	})
+}
+
+// acceptsGzip reports whether the client of `r` accepts gzip-compressed responses
+func acceptsGzip(r *http.Request) bool {
+	for _, enc := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(enc), ";")
+		if strings.EqualFold(strings.TrimSpace(enc), "gzip") {
+			return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+		}
+	}
+	return false
+}
+
+// matchesETag reports whether the If-None-Match header value `header` matches `etag`,
+// comparing weakly as required for If-None-Match
+func matchesETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package remotelist
+
+import (
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestHandlerChain(t *testing.T) {
+	upstream := newTestRemote(t, "b.com\na.com\n")
+	first := newTestList(t, upstream.URL)
+	var notModified int
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := httptest.NewRecorder()
+		first.Handler().ServeHTTP(rec, r)
+		if rec.Code == http.StatusNotModified {
+			notModified++
+		}
+		maps.Copy(w.Header(), rec.Header())
+		w.WriteHeader(rec.Code)
+		w.Write(rec.Body.Bytes())
+	}))
+	defer mirror.Close()
+
+	// The second list uses the first one as its remote location
+	second := newTestList(t, mirror.URL)
+	if got := second.List(); !slices.Equal(got, []string{"a.com", "b.com"}) {
+		t.Fatalf("List() = %q, want [a.com b.com]", got)
+	}
+
+	// Unchanged records are not transferred again
+	if err := second.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if notModified != 1 {
+		t.Errorf("got %d responses with 304, want 1", notModified)
+	}
+	first.Add("c.com")
+	if err := second.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := second.List(); !slices.Equal(got, []string{"a.com", "b.com", "c.com"}) {
+		t.Errorf("List() = %q, want [a.com b.com c.com]", got)
+	}
+
+	// The ETag changes with the records and matching requests get 304
+	req, _ := http.NewRequest(http.MethodGet, mirror.URL, nil)
+	resp, err := mirror.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	req.Header.Set("If-None-Match", etag)
+	resp, err = mirror.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if etag == "" || resp.StatusCode != http.StatusNotModified {
+		t.Errorf("got status %d for ETag %q, want 304", resp.StatusCode, etag)
+	}
+}
+
+func TestHandlerCache(t *testing.T) {
+	rl, err := NewFromStrings([]string{"a.com", "b.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+	get := func(etag string, gzipped bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if gzipped {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+		rec := httptest.NewRecorder()
+		rl.Handler().ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Requests share the response until the records change, revalidations don't build it again
+	first := get("", false)
+	served := rl.served.Load()
+	if revalidated := get(first.Header().Get("ETag"), false); revalidated.Code != http.StatusNotModified {
+		t.Errorf("got status %d for a matching ETag, want 304", revalidated.Code)
+	}
+	get("", true)
+	if get("", true).Body.Len() == 0 || rl.served.Load() != served {
+		t.Error("the response was built again for unchanged records")
+	}
+
+	rl.Add("c.com")
+	second := get(first.Header().Get("ETag"), false)
+	if second.Code != http.StatusOK || second.Body.String() != "a.com\nb.com\nc.com\n" || rl.served.Load() == served {
+		t.Errorf("got status %d and body %q after a change", second.Code, second.Body)
+	}
+	rl.Remove("c.com")
+	if third := get("", false); third.Header().Get("ETag") != first.Header().Get("ETag") {
+		t.Errorf("got ETag %s for the original records, want %s", third.Header().Get("ETag"), first.Header().Get("ETag"))
+	}
+}
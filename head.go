@@ -0,0 +1,32 @@
+package remotelist
+
+import (
+	"context"
+	"net/http"
+)
+
+// unchanged checks with a HEAD request whether the list at `remote` still has the Last-Modified and
+// Content-Length headers stored in `meta`. Last-Modified must have been stored, a list of the same size may
+// still have changed. Content-Length is only compared if it was stored.
+// Any failure, e.g. a server that doesn't allow HEAD requests, counts as changed.
+func (rl *RemoteList) unchanged(ctx context.Context, remote string, meta metadata) bool {
+	if meta.LastModified == "" {
+		return false
+	}
+	req, err := rl.newRequestMethod(ctx, http.MethodHead, remote)
+	if err != nil {
+		return false
+	}
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return false
+	}
+	if resp.Header.Get("Last-Modified") != meta.LastModified {
+		return false
+	}
+	return meta.ContentLength == 0 || resp.ContentLength == meta.ContentLength
+}
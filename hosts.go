@@ -0,0 +1,84 @@
+package remotelist
+
+import (
+	"io"
+	"iter"
+	"net/netip"
+	"strings"
+)
+
+// hostsBoilerplate contains the hostnames that hosts files map to loopback or broadcast addresses by convention
+var hostsBoilerplate = map[string]struct{}{
+	"localhost":             {},
+	"localhost.localdomain": {},
+	"local":                 {},
+	"broadcasthost":         {},
+	"ip6-localhost":         {},
+	"ip6-loopback":          {},
+	"ip6-localnet":          {},
+	"ip6-mcastprefix":       {},
+	"ip6-allnodes":          {},
+	"ip6-allrouters":        {},
+	"ip6-allhosts":          {},
+	"0.0.0.0":               {},
+}
+
+var (
+	// The `HostsFileLine` function parses lines in the /etc/hosts format (`0.0.0.0 ads.example.com # comment`).
+	// It strips the IP column, inline comments and whitespace and returns the hostname. Lines that only
+	// contain a hostname are accepted as well. If a line lists multiple hostnames, the first one that is
+	// not localhost or broadcast boilerplate is returned, use WithHostsFile to get all of them.
+	// Lines without such a hostname are excluded.
+	HostsFileLineFunc = func(line string) (parsed string, include bool) {
+		for host := range hostsFileHosts(line) {
+			return host, true
+		}
+		return "", false
+	}
+)
+
+// hostsFileHosts returns an iterator over the hostnames of a line in the /etc/hosts format
+// that are not localhost or broadcast boilerplate
+func hostsFileHosts(line string) iter.Seq[string] {
+	return func(yield func(string) bool) {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 1 {
+			fields = fields[1:]
+		} else if len(fields) == 1 {
+			if _, err := netip.ParseAddr(fields[0]); err == nil {
+				return
+			}
+		}
+		for _, host := range fields {
+			if _, ok := hostsBoilerplate[strings.ToLower(host)]; ok {
+				continue
+			}
+			if !yield(host) {
+				return
+			}
+		}
+	}
+}
+
+// splitHosts returns a splitFunc that splits the content into lines with `lines` and yields
+// every hostname of each line in the /etc/hosts format that is not localhost or broadcast boilerplate
+func splitHosts(lines splitFunc) splitFunc {
+	return func(r io.Reader, malformed *int) iter.Seq2[string, error] {
+		return func(yield func(string, error) bool) {
+			for line, err := range lines(r, malformed) {
+				if err != nil {
+					yield("", err)
+					return
+				}
+				for host := range hostsFileHosts(line) {
+					if !yield(host, nil) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package remotelist
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestHostsFileLineFunc(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    string
+		include bool
+	}{
+		{"0.0.0.0 ads.example.com", "ads.example.com", true},
+		{"0.0.0.0\tads.example.com", "ads.example.com", true},
+		{"  127.0.0.1 \t ads.example.com  ", "ads.example.com", true},
+		{"0.0.0.0 ads.example.com # tracking", "ads.example.com", true},
+		{"0.0.0.0 ads.example.com#tracking", "ads.example.com", true},
+		{"0.0.0.0 ads.example.com ads2.example.com", "ads.example.com", true},
+		{"127.0.0.1 localhost ads.example.com", "ads.example.com", true},
+		{"::1 ip6-localhost ip6-loopback", "", false},
+		{"255.255.255.255 broadcasthost", "", false},
+		{"ads.example.com", "ads.example.com", true},
+		{"0.0.0.0", "", false},
+		{"# 0.0.0.0 ads.example.com", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, include := HostsFileLineFunc(tt.line)
+		if got != tt.want || include != tt.include {
+			t.Errorf("HostsFileLineFunc(%q) = %q, %v, want %q, %v", tt.line, got, include, tt.want, tt.include)
+		}
+	}
+}
+
+func TestWithHostsFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{"tabs", "0.0.0.0\tads.example.com\n0.0.0.0\t\tads2.example.com\t\n", []string{"ads.example.com", "ads2.example.com"}},
+		{"multiple hosts", "0.0.0.0 ads.example.com ads2.example.com\n127.0.0.1 localhost\tads3.example.com\n", []string{"ads.example.com", "ads2.example.com", "ads3.example.com"}},
+		{"trailing comments", "0.0.0.0 ads.example.com # ads2.example.com\n0.0.0.0 ads3.example.com#comment\n", []string{"ads.example.com", "ads3.example.com"}},
+		{"boilerplate", "# comment\n127.0.0.1 localhost\n::1 ip6-localhost ip6-loopback\n255.255.255.255 broadcasthost\n0.0.0.0\n", []string{}},
+		{"CRLF", "0.0.0.0 ads.example.com\r\n0.0.0.0 ads2.example.com\r\n", []string{"ads.example.com", "ads2.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromReader(strings.NewReader(tt.data), WithHostsFile())
+			if err != nil {
+				t.Fatalf("NewFromReader: %v", err)
+			}
+			defer rl.Close()
+			if got := rl.List(); !slices.Equal(got, tt.want) {
+				t.Errorf("List() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := NewFromReader(strings.NewReader(""), WithHostsFile(), WithJSON("")); err == nil {
+		t.Error("WithHostsFile combined with WithJSON was accepted")
+	}
+}
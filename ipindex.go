@@ -0,0 +1,163 @@
+package remotelist
+
+import (
+	"net/netip"
+	"strings"
+)
+
+// ipTrie is a binary radix tree over network prefixes. It is used to index CIDR records
+// so that containment queries only walk the bits of the address instead of scanning all records.
+type ipTrie struct {
+	v4 *ipTrieNode
+	v6 *ipTrieNode
+}
+
+// ipTrieNode is a node of an ipTrie
+type ipTrieNode struct {
+	children [2]*ipTrieNode
+	ends     int // number of prefixes ending at this node
+}
+
+// newIPTrie creates an empty ipTrie
+func newIPTrie() *ipTrie {
+	return &ipTrie{v4: &ipTrieNode{}, v6: &ipTrieNode{}}
+}
+
+// parseNetwork parses `s` as a network in CIDR notation or as a single address,
+// which is treated as a network with all bits set. IPv4-mapped IPv6 addresses are unmapped.
+func parseNetwork(s string) (netip.Prefix, bool) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, false
+		}
+		if p.Addr().Is4In6() {
+			if p.Bits() < 96 {
+				return netip.Prefix{}, false
+			}
+			p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+		}
+		return p.Masked(), true
+	}
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, false
+	}
+	addr = addr.Unmap().WithZone("")
+	return netip.PrefixFrom(addr, addr.BitLen()), true
+}
+
+// root returns the root node for the address family of `addr`
+func (t *ipTrie) root(addr netip.Addr) *ipTrieNode {
+	if addr.Is4() {
+		return t.v4
+	}
+	return t.v6
+}
+
+// insert adds the network `p`. Inserting the same network twice requires removing it twice.
+func (t *ipTrie) insert(p netip.Prefix) {
+	node := t.root(p.Addr())
+	b := p.Addr().AsSlice()
+	for i := 0; i < p.Bits(); i++ {
+		bit := b[i/8] >> (7 - i%8) & 1
+		if node.children[bit] == nil {
+			node.children[bit] = &ipTrieNode{}
+		}
+		node = node.children[bit]
+	}
+	node.ends++
+}
+
+// remove removes the network `p`. Removing a network that is not in the trie is a no-op.
+// Emptied nodes are kept, they are dropped when the index is rebuilt on the next reload.
+func (t *ipTrie) remove(p netip.Prefix) {
+	node := t.root(p.Addr())
+	b := p.Addr().AsSlice()
+	for i := 0; i < p.Bits() && node != nil; i++ {
+		node = node.children[b[i/8]>>(7-i%8)&1]
+	}
+	if node != nil && node.ends > 0 {
+		node.ends--
+	}
+}
+
+// contains checks if any network in the trie contains `addr`
+func (t *ipTrie) contains(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	node := t.root(addr)
+	b := addr.AsSlice()
+	for i := 0; node != nil; i++ {
+		if node.ends > 0 {
+			return true
+		}
+		if i == len(b)*8 {
+			return false
+		}
+		node = node.children[b[i/8]>>(7-i%8)&1]
+	}
+	return false
+}
+
+// newIPIndex builds an ipTrie of all `records` that are valid networks, nil if disabled
+func (rl *RemoteList) newIPIndex(records map[string]struct{}) *ipTrie {
+	if !rl.indexIP {
+		return nil
+	}
+	t := newIPTrie()
+	for rec := range records {
+		if p, ok := parseNetwork(rec); ok {
+			t.insert(p)
+		}
+	}
+	return t
+}
+
+// dropInvalidNetworks removes the records that aren't valid networks from `records` and `overridden`
+// if the list is an IP list and returns how many records have been removed
+func (rl *RemoteList) dropInvalidNetworks(records, overridden map[string]struct{}) int {
+	if !rl.indexIP {
+		return 0
+	}
+	n := 0
+	for rec := range records {
+		if _, ok := parseNetwork(rec); !ok {
+			delete(records, rec)
+			delete(overridden, rec)
+			n++
+		}
+	}
+	return n
+}
+
+// HasIP checks if any network (CIDR or single address) in the RemoteList contains the address `addr`.
+// It returns `false` if `addr` is not a valid IPv4 or IPv6 address.
+func (rl *RemoteList) HasIP(addr string) bool {
+	a, err := netip.ParseAddr(addr)
+	if err != nil {
+		return false
+	}
+	return rl.HasAddr(a)
+}
+
+// HasAddr checks if any network (CIDR or single address) in the RemoteList contains `addr`.
+// With WithIPIndex this walks at most 128 nodes, otherwise all records are parsed and scanned.
+func (rl *RemoteList) HasAddr(addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	addr = addr.WithZone("")
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	if rl.networks != nil {
+		return rl.networks.contains(addr)
+	}
+	addr = addr.Unmap()
+	found := false
+	rl.eachRecord(func(rec string) bool {
+		p, ok := parseNetwork(rec)
+		found = ok && p.Contains(addr)
+		return !found
+	})
+	return found
+}
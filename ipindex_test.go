@@ -0,0 +1,63 @@
+package remotelist
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+)
+
+func TestHasIP(t *testing.T) {
+	data := "203.0.113.0/24\n198.51.100.7\n2001:db8::/32\n2001:db8:ffff::1\nnot-an-ip\n10.0.0.0/33\n"
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"203.0.113.1", true},
+		{"203.0.114.1", false},
+		{"198.51.100.7", true},
+		{"198.51.100.8", false},
+		{"::ffff:203.0.113.9", true},
+		{"2001:db8:1::1", true},
+		{"2001:db9::1", false},
+		{"10.0.0.1", false},
+		{"not-an-ip", false},
+		{"", false},
+	}
+	for _, index := range []bool{false, true} {
+		var opts []Option
+		if index {
+			opts = append(opts, WithIPIndex())
+		}
+		rl, err := NewFromReader(strings.NewReader(data), opts...)
+		if err != nil {
+			t.Fatalf("NewFromReader: %v", err)
+		}
+		defer rl.Close()
+		for _, tt := range tests {
+			if got := rl.HasIP(tt.addr); got != tt.want {
+				t.Errorf("index=%v: HasIP(%q) = %v, want %v", index, tt.addr, got, tt.want)
+			}
+		}
+		if got := rl.HasAddr(netip.MustParseAddr("2001:db8::1")); !got {
+			t.Errorf("index=%v: HasAddr(2001:db8::1) = false", index)
+		}
+	}
+
+	// With the index, invalid lines are skipped and counted
+	rl, err := NewFromReader(strings.NewReader(data), WithIPIndex())
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	defer rl.Close()
+	if n, malformed := rl.Len(), rl.Stats().MalformedEntries; n != 4 || malformed != 2 {
+		t.Errorf("got %d records and %d malformed entries, want 4 and 2", n, malformed)
+	}
+	rl, err = NewFromStrings(strings.Fields(data), WithIPIndex())
+	if err != nil {
+		t.Fatalf("NewFromStrings: %v", err)
+	}
+	defer rl.Close()
+	if n, malformed := rl.Len(), rl.Stats().MalformedEntries; n != 4 || malformed != 2 {
+		t.Errorf("NewFromStrings: got %d records and %d malformed entries, want 4 and 2", n, malformed)
+	}
+}
@@ -0,0 +1,31 @@
+package remotelist
+
+import "iter"
+
+// All returns an iterator over all records in no particular order. Unlike List, it doesn't copy or sort the records.
+//
+// The read lock is held while iterating, so the loop body must not modify the RemoteList (e.g. call Add)
+// or it deadlocks. Changes by other goroutines are blocked until the loop ends.
+//
+//	for rec := range rl.All() {
+//		fmt.Println(rec)
+//	}
+func (rl *RemoteList) All() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		rl.rlock()
+		defer rl.mu.RUnlock()
+		rl.eachRecord(yield)
+	}
+}
+
+// ForEach calls `fn` for each record in no particular order until `fn` returns `true`.
+//
+// The read lock is held while iterating, so `fn` must not modify the RemoteList (e.g. call Add)
+// or it deadlocks. If `fn` panics, the lock is released before the panic propagates.
+func (rl *RemoteList) ForEach(fn func(record string) (stop bool)) {
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	rl.eachRecord(func(rec string) bool {
+		return !fn(rec)
+	})
+}
@@ -0,0 +1,83 @@
+package remotelist
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// jsonList is the JSON representation of a RemoteList with metadata
+type jsonList struct {
+	Remote       string     `json:"remote,omitempty"`
+	Source       string     `json:"source,omitempty"`
+	LastDownload *time.Time `json:"last_download,omitempty"`
+	Records      []string   `json:"records"`
+}
+
+// MarshalJSON encodes the records as a sorted JSON array. With WithJSONMetadata, they are
+// encoded as an object that also holds the remote location and the time of the last download.
+// It implements json.Marshaler.
+func (rl *RemoteList) MarshalJSON() ([]byte, error) {
+	records := rl.List()
+	if !rl.jsonMeta {
+		return json.Marshal(records)
+	}
+
+	rl.mu.RLock()
+	data := jsonList{
+		Remote:  rl.fileRemote,
+		Source:  rl.source,
+		Records: records,
+	}
+	if !rl.lastDownload.IsZero() {
+		lastDownload := rl.lastDownload
+		data.LastDownload = &lastDownload
+	}
+	rl.mu.RUnlock()
+	return json.Marshal(data)
+}
+
+// UnmarshalJSON replaces the records with those encoded in `data`, which can be a JSON array
+// of records or an object as produced by MarshalJSON with WithJSONMetadata. Metadata is ignored.
+// A zero value RemoteList is initialized with the default functions and, like one created by NewFromJSON,
+// never downloads anything. It implements json.Unmarshaler.
+func (rl *RemoteList) UnmarshalJSON(data []byte) error {
+	var records []string
+	if err := json.Unmarshal(data, &records); err != nil {
+		list := jsonList{}
+		if errObject := json.Unmarshal(data, &list); errObject != nil {
+			return err
+		}
+		records = list.Records
+	}
+
+	if rl.mu == nil {
+		fresh, err := newRemoteList("", "")
+		if err != nil {
+			return err
+		}
+		*rl = *fresh
+		rl.static = true
+	}
+
+	m := make(map[string]struct{}, len(records))
+	for _, rec := range records {
+		m[rl.normalize(rec)] = struct{}{}
+	}
+	rl.setRecords(m, map[string]struct{}{}, nil)
+	return nil
+}
+
+// NewFromJSON creates a new RemoteList instance from JSON produced by MarshalJSON without
+// downloading anything. The RemoteList has no local file or remote location. Refresh does nothing.
+func NewFromJSON(data []byte, opts ...Option) (*RemoteList, error) {
+	rl, err := newRemoteList("", "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	rl.static = true
+	if err := rl.UnmarshalJSON(data); err != nil {
+		_ = rl.removeEphemeral()
+		return nil, err
+	}
+	return rl, nil
+}
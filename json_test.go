@@ -0,0 +1,55 @@
+package remotelist
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	remote := newTestRemote(t, "c.com\na.com\nb.com\n")
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"array", nil},
+		{"with metadata", []Option{WithJSONMetadata()}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl := newTestList(t, remote.URL, tt.opts...)
+			data, err := json.Marshal(rl)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			// The encoding is deterministic
+			if again, _ := json.Marshal(rl); string(again) != string(data) {
+				t.Errorf("Marshal is not deterministic: %s and %s", data, again)
+			}
+
+			restored, err := NewFromJSON(data)
+			if err != nil {
+				t.Fatalf("NewFromJSON: %v", err)
+			}
+			defer restored.Close()
+			if got := restored.List(); !slices.Equal(got, rl.List()) {
+				t.Errorf("restored List() = %q, want %q", got, rl.List())
+			}
+			// The restored list has nothing to download
+			if err := restored.Refresh(true); err != nil || restored.Len() != 3 {
+				t.Errorf("Refresh of the restored list: %v, %d records", err, restored.Len())
+			}
+
+			var zero RemoteList
+			if err := json.Unmarshal(data, &zero); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got := zero.List(); !slices.Equal(got, rl.List()) {
+				t.Errorf("unmarshaled List() = %q, want %q", got, rl.List())
+			}
+			if err := zero.Refresh(true); err != nil {
+				t.Errorf("Refresh of the unmarshaled list: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package remotelist
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// lazyLoader loads a list created with WithLazyInit on first use
+type lazyLoader struct {
+	mu        sync.Mutex   // Held by the caller that loads the list, the others wait for it
+	loaded    atomic.Bool  // Whether the first load has been done, successful or not
+	callbacks atomic.Int32 // Number of user functions that are running, see callback
+}
+
+// EnsureLoaded loads the list unless it has been loaded already and returns the error if that fails.
+// Use it with WithLazyInit to load the list at a convenient time, e.g. with a deadline, or to retry a
+// failed first load. Concurrent calls share a single load.
+func (rl *RemoteList) EnsureLoaded(ctx context.Context) error {
+	rl.mu.RLock()
+	loaded := rl.loaded
+	rl.mu.RUnlock()
+	if loaded {
+		return nil
+	}
+	return rl.load(ctx, false)
+}
+
+// lazyInit loads the list on first use if it has been created with WithLazyInit. Callers that use it
+// at the same time wait for the same load. If it fails, the list stays empty until EnsureLoaded or
+// Refresh succeed, LastError returns the error. Lookups from user functions that are called during the
+// load, e.g. by a ProgressFunc or a logger, don't wait for it, as the load waits for them.
+func (rl *RemoteList) lazyInit() {
+	if rl.lazy == nil || rl.lazy.loaded.Load() || rl.lazy.callbacks.Load() > 0 {
+		return
+	}
+	rl.lazy.mu.Lock()
+	defer rl.lazy.mu.Unlock()
+	if !rl.lazy.loaded.Load() {
+		_ = rl.EnsureLoaded(context.Background())
+		rl.lazy.loaded.Store(true)
+	}
+}
+
+// callback runs `fn`, which calls a user function such as the logger or the OnChangeFunc.
+// Meanwhile lookups don't wait for the first load of a lazy list, which may be the caller (see lazyInit).
+func (rl *RemoteList) callback(fn func()) {
+	if rl.lazy != nil {
+		rl.lazy.callbacks.Add(1)
+		defer rl.lazy.callbacks.Add(-1)
+	}
+	fn()
+}
@@ -0,0 +1,59 @@
+package remotelist
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWithLazyInit(t *testing.T) {
+	remote := newTestRemote(t, "a.com\nb.com\n")
+	rl := newTestList(t, remote.URL, WithLazyInit())
+	m := NewManager()
+	m.Add("lazy", rl)
+
+	// Stats are read without loading the list
+	if stats := rl.Stats(); stats.RecordCount != 0 || remote.hits.Load() != 0 {
+		t.Errorf("got %d records after %d downloads before the first use", stats.RecordCount, remote.hits.Load())
+	}
+	if stats := m.Stats()["lazy"]; stats.RecordCount != 0 || remote.hits.Load() != 0 {
+		t.Errorf("got %d records after %d downloads before the first use", stats.RecordCount, remote.hits.Load())
+	}
+
+	if !rl.Has("a.com") || rl.Len() != 2 || remote.hits.Load() != 1 {
+		t.Errorf("got records %q after %d downloads, want 2 after 1", rl.List(), remote.hits.Load())
+	}
+}
+
+func TestWithLazyInitCallbacks(t *testing.T) {
+	remote := newTestRemote(t, "a.com\nb.com\n")
+
+	// Lookups from the callbacks of the first load don't wait for it
+	var rl *RemoteList
+	lens := make(chan int, 1)
+	progress := func(bytesRead, totalBytes int64) {
+		select {
+		case lens <- rl.Len():
+		default:
+		}
+	}
+	rl, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), remote.URL, WithLazyInit(), WithProgress(progress))
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+
+	done := make(chan bool)
+	go func() { done <- rl.Has("a.com") }()
+	select {
+	case ok := <-done:
+		if !ok {
+			t.Errorf("List() = %q, want [a.com b.com]", rl.List())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the lookup from the ProgressFunc waits for the load that calls it")
+	}
+	if n := <-lens; n != 0 {
+		t.Errorf("Len() from the ProgressFunc = %d, want 0 while loading", n)
+	}
+}
@@ -0,0 +1,55 @@
+package remotelist
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// lockPollInterval is the interval at which a lock held by another process is tried again
+const lockPollInterval = 50 * time.Millisecond
+
+// lockFile returns the path of the file that is locked while `fileLocal` is downloaded
+func lockFile(fileLocal string) string {
+	return fileLocal + ".lock"
+}
+
+// lockLocal acquires the advisory lock of the local file that is shared by all processes using it,
+// if enabled with WithFileLock. It waits until the lock is free, the lock timeout has passed or `ctx`
+// is canceled. The returned function releases the lock.
+func (rl *RemoteList) lockLocal(ctx context.Context) (unlock func(), err error) {
+	if _, ok := rl.storage.(FileStorage); !ok || rl.lockTimeout == 0 {
+		return func() {}, nil
+	}
+
+	f, err := os.OpenFile(lockFile(rl.fileLocal), os.O_CREATE|os.O_RDWR, cmp.Or(rl.fileMode, 0644))
+	if err != nil {
+		return nil, fmt.Errorf("%w, could not open lock file: %w", ErrWriteLocal, err)
+	}
+	deadline := time.Now().Add(rl.lockTimeout)
+	for {
+		ok, err := tryLockFile(f)
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%w, could not lock local file: %w", ErrWriteLocal, err)
+		}
+		if ok {
+			return func() {
+				_ = unlockFile(f)
+				f.Close()
+			}, nil
+		}
+		if !time.Now().Before(deadline) {
+			f.Close()
+			return nil, fmt.Errorf("%w after %s", ErrLockTimeout, rl.lockTimeout)
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, context.Cause(ctx)
+		case <-time.After(min(lockPollInterval, time.Until(deadline))):
+		}
+	}
+}
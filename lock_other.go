@@ -0,0 +1,15 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package remotelist
+
+import "os"
+
+// tryLockFile always succeeds, file locking is not supported on this platform
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+// unlockFile does nothing, file locking is not supported on this platform
+func unlockFile(f *os.File) error {
+	return nil
+}
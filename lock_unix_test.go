@@ -0,0 +1,82 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package remotelist
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// holdLock takes the flock of the local file `local` like another process would and returns a function releasing it
+func holdLock(t *testing.T, local string) func() {
+	t.Helper()
+	f, err := os.OpenFile(lockFile(local), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		t.Fatal(err)
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}
+}
+
+func TestWithFileLock(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	local := filepath.Join(t.TempDir(), "list.txt")
+	logs := &lockedBuffer{}
+	logger := slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	rl, err := NewWithOptions(local, remote.URL, WithMaxAge(time.Hour), WithFileLock(500*time.Millisecond), WithLogger(logger))
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	stale := func() {
+		old := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(local, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Another process holds the lock for longer than the timeout, the local file is used meanwhile
+	stale()
+	release := holdLock(t, local)
+	if err := rl.Refresh(false); err != nil || !errors.Is(rl.LastError(), ErrLockTimeout) {
+		t.Errorf("Refresh: %v, got LastError() = %v, want ErrLockTimeout", err, rl.LastError())
+	}
+
+	// Another process downloads the list while holding the lock, its download is used
+	hits := remote.hits.Load()
+	errs := make(chan error)
+	go func() { errs <- rl.Refresh(false) }()
+	time.Sleep(3 * lockPollInterval)
+	if err := os.WriteFile(local, []byte("b.com\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	release()
+	if err := <-errs; err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := rl.List(); !slices.Equal(got, []string{"b.com"}) || remote.hits.Load() != hits {
+		t.Errorf("got records %q after %d downloads, want [b.com] without downloading", got, remote.hits.Load()-hits)
+	}
+	if !strings.Contains(logs.String(), "list downloaded by another process") {
+		t.Errorf("the download wasn't skipped after waiting for the lock:\n%s", logs)
+	}
+
+	// Once the lock is free, stale lists are downloaded as usual
+	stale()
+	if err := rl.Refresh(false); err != nil || !rl.Has("a.com") || remote.hits.Load() != hits+1 {
+		t.Errorf("Refresh: %v, got records %q", err, rl.List())
+	}
+}
@@ -0,0 +1,20 @@
+package remotelist
+
+import (
+	"context"
+	"log/slog"
+)
+
+// log logs `msg` with the configured logger, if any
+func (rl *RemoteList) log(level slog.Level, msg string, args ...any) {
+	if rl.logger == nil {
+		return
+	}
+	name := rl.fileLocal
+	if name == "" {
+		name = rl.fileRemote
+	}
+	rl.callback(func() {
+		rl.logger.Log(context.Background(), level, msg, append([]any{"list", name}, args...)...)
+	})
+}
@@ -1,14 +1,32 @@
 package remotelist
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
+	"hash/maphash"
 	"io"
+	"iter"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
 )
 
 // A `SearchFunc` is used to search the given `records` and return a list of all matches.
@@ -22,12 +40,62 @@ type HasFunc func(records map[string]struct{}, term string) (exists bool)
 // This function can be used to filter out content before the file is saved to disk.
 type DataFilterFunc func(source string) string
 
+// A `StreamFilterFunc` copies the downloaded content from `src` to `dst` while it is saved to disk.
+//
+// Unlike a `DataFilterFunc` it doesn't need the whole content in memory, so it is preferred if both are set.
+type StreamFilterFunc func(dst io.Writer, src io.Reader) error
+
 // A `DataLineFunc` is run over each line of the locally stored file when reading it.
 //
 // This function can be used to transform lines on the fly as well as exclude them from the index (`include = false`).
 type DataLineFunc func(line string) (parsed string, include bool)
 
+// An `OnChangeFunc` is called after a reload changed the records.
+//
+// It receives the sorted records that have been added and removed by the reload.
+type OnChangeFunc func(added, removed []string)
+
+// A `ProgressFunc` is called periodically while a list is downloaded.
+//
+// It receives the number of bytes read so far and the total size of the download, which is -1 if it is unknown.
+type ProgressFunc func(bytesRead, totalBytes int64)
+
+// A `NormalizeFunc` is applied to every record and to the terms passed to Has, HasPrefix, HasSuffix and Search.
+//
+// This function can be used to make sure records and queries are compared in the same form, e.g. without URL schemes.
+type NormalizeFunc func(value string) string
+
+// A `RequestModifierFunc` is run on every download request before it is sent.
+//
+// This function can be used to add authentication or other headers required by the list source.
+type RequestModifierFunc func(req *http.Request)
+
+// DefaultMaxAge is the maximum age of the local file used by NewWithOptions unless WithMaxAge is given.
+const DefaultMaxAge = 24 * time.Hour
+
+const (
+	// RefreshAlways is a maxAge that downloads the list on every refresh, no matter how old the local file is.
+	RefreshAlways time.Duration = 0
+
+	// RefreshNever is a maxAge that never downloads the list again once there is a local file,
+	// it is only downloaded if there is none (or if a refresh is forced).
+	RefreshNever time.Duration = math.MaxInt64
+)
+
+// DefaultUserAgent is the User-Agent header sent with download requests unless WithUserAgent is given.
+const DefaultUserAgent = "remotelist/1.x (+https://github.com/toxyl/remotelist)"
+
+// DefaultDirMode is the mode of the directories created for the local file unless WithDirMode is given.
+const DefaultDirMode os.FileMode = 0755
+
+// DefaultMaxLineLength is the maximum length of a line of the local file unless WithMaxLineLength is given.
+const DefaultMaxLineLength = 1024 * 1024
+
 var (
+	// The default HTTP client is used to download lists unless another client is configured with WithHTTPClient.
+	// Unlike `http.DefaultClient` it gives up on downloads that take longer than 5 minutes.
+	DefaultHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+
 	// The default `Search` function performs a case-insensitive search for all records that contain
 	// the search term and returns a slice with the results.
 	DefaultSearchFunc = func(records map[string]struct{}, term string) []string {
@@ -60,6 +128,53 @@ var (
 		return false
 	}
 
+	// The `LowercaseHas` function looks up the lowercased search term with a single map lookup.
+	// Matching is case-insensitive as long as the records are lowercase, which WithFastHas ensures.
+	LowercaseHasFunc = func(records map[string]struct{}, term string) bool {
+		_, ok := records[strings.ToLower(term)]
+		return ok
+	}
+
+	// The `CaseSensitiveHas` function checks if `records` has the search `term` with a single map lookup.
+	// Matching is case-sensitive.
+	CaseSensitiveHasFunc = func(records map[string]struct{}, term string) bool {
+		_, ok := records[term]
+		return ok
+	}
+
+	// The `CaseSensitiveHasPrefix` function checks if any record starts with the search term. Matching is case-sensitive.
+	CaseSensitiveHasPrefixFunc = func(records map[string]struct{}, term string) bool {
+		for rec := range records {
+			if strings.HasPrefix(rec, term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The `CaseSensitiveHasSuffix` function checks if any record ends with the search term. Matching is case-sensitive.
+	CaseSensitiveHasSuffixFunc = func(records map[string]struct{}, term string) bool {
+		for rec := range records {
+			if strings.HasSuffix(rec, term) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The `CaseSensitiveSearch` function searches for all records that contain the search term
+	// and returns a sorted slice with the results. Matching is case-sensitive.
+	CaseSensitiveSearchFunc = func(records map[string]struct{}, term string) []string {
+		res := []string{}
+		for rec := range records {
+			if strings.Contains(rec, term) {
+				res = append(res, rec)
+			}
+		}
+		sort.Strings(res)
+		return res
+	}
+
 	// The default `HasPrefix` function checks if any record starts with the search term. Matching is case-insensitive.
 	DefaultHasPrefixFunc = func(records map[string]struct{}, term string) bool {
 		term = strings.ToLower(term)
@@ -85,121 +200,1336 @@ var (
 
 // RemoteList represents a remote list and provides methods for managing it.
 type RemoteList struct {
-	fnSearch    SearchFunc     // Function for searching a term in the list
-	fnHas       HasFunc        // Function for checking if a term exists in the list
-	fnHasPrefix HasFunc        // Function for checking if a prefix exists in the list
-	fnHasSuffix HasFunc        // Function for checking if a suffix exists in the list
-	fnDataFiler DataFilterFunc // Function for preprocessing data before writing to file
-	fnDataLine  DataLineFunc   // Function for processing each line of data read from file
-	maxAge      time.Duration  // Maximum age of the local list file before redownloading
-	fileLocal   string         // Filepath for storing the list locally
-	fileRemote  string         // Filepath from which to download the list
-	mu          *sync.Mutex
-	records     map[string]struct{} // records stores the data from the list file
+	fnSearch        SearchFunc          // Function for searching a term in the list
+	fnMatch         MatchFunc           // Function for matching a term in SearchDetailed
+	fnDistance      DistanceFunc        // Function for computing edit distances in SearchFuzzy
+	fnHas           HasFunc             // Function for checking if a term exists in the list
+	fnHasPrefix     HasFunc             // Function for checking if a prefix exists in the list
+	fnHasSuffix     HasFunc             // Function for checking if a suffix exists in the list
+	fnDataFiler     DataFilterFunc      // Function for preprocessing data before writing to file
+	fnStreamFilter  StreamFilterFunc    // Function for filtering the downloaded data while writing it to file
+	fnDataLine      DataLineFunc        // Function for processing each line of data read from file
+	commentPrefixes []string            // Prefixes of comment lines, nil for the DataLineFunc to decide
+	commentInline   string              // Marker of inline comments, empty if disabled
+	fnChange        OnChangeFunc        // Function that is called when a reload changes the records
+	fnProgress      ProgressFunc        // Function for reporting the progress of downloads
+	fnValidate      ValidatorFunc       // Function for validating downloaded lists before they are committed
+	fnNormalize     NormalizeFunc       // Function for normalizing records and query terms
+	logger          *slog.Logger        // Logger for downloads and loads, nil means silent
+	store           recordStore         // Alternative storage for the parsed lines, nil if the records are stored by the RemoteList
+	split           splitFunc           // Function for splitting the local file into raw records
+	hostsFile       bool                // Whether the local file is in the /etc/hosts format and every hostname of a line is a raw record
+	maxLine         int                 // Maximum length of a line in bytes
+	maxAge          time.Duration       // Maximum age of the local list file before redownloading
+	jitter          float64             // Fraction by which the maxAge is randomized per refresh
+	fileLocal       string              // Filepath for storing the list locally
+	fileRemote      string              // Filepath from which to download the list
+	storage         Storage             // Stores the local file and its sidecar files
+	memoryOnly      bool                // Whether the list is kept in memory only, without a local file
+	ephemeral       bool                // Whether the local file is kept in a temporary directory that is removed by Close
+	ephemeralDir    string              // Temporary directory of the local file if enabled with WithEphemeralCache
+	static          bool                // Whether the records have been passed to the constructor, they are never loaded again
+	dirMode         os.FileMode         // Mode of the directories created for the local file, 0 if they aren't created
+	fileMode        os.FileMode         // Permissions of the local file and its sidecar files, 0 to keep those of the existing file
+	lockTimeout     time.Duration       // Maximum wait for the lock of the local file, 0 if it isn't locked
+	mirrors         []string            // Filepaths from which to download the list if fileRemote fails
+	overrides       []string            // Filepaths of local files whose records are merged into the list
+	source          string              // Filepath from which the list was downloaded the last time
+	lastDownload    time.Time           // Time of the last successful download
+	lastRefresh     time.Time           // Time of the last refresh that loaded up-to-date records
+	lastDuration    time.Duration       // Duration of the last successful download
+	refreshAge      time.Duration       // Randomized maxAge until the next download
+	jsonMeta        bool                // Whether MarshalJSON includes metadata
+	client          *http.Client        // HTTP client used to download the list
+	tlsConfig       *tls.Config         // TLS configuration applied to the transport of the client
+	rootCAs         *x509.CertPool      // Certificate authorities trusted by the client in addition to the system ones
+	proxy           *url.URL            // Proxy used for downloads, nil for the proxy of the client
+	fetcher         Fetcher             // Retrieves lists instead of the HTTP client, nil for HTTP
+	headers         http.Header         // Additional headers sent with every download request
+	userAgent       string              // User-Agent header sent with every download request
+	fnRequest       RequestModifierFunc // Function for modifying download requests before they are sent
+	headCheck       bool                // Whether to check with a HEAD request if the list changed before downloading it
+	resume          bool                // Whether to resume partial downloads with range requests
+	timeout         time.Duration       // Maximum duration of a download including reading the response, 0 means no limit
+	retries         int                 // Number of times a failed download is retried
+	retryBackoff    time.Duration       // Delay before the first retry, doubled for every further retry
+	maxSize         int64               // Maximum size of a download in bytes, 0 means no limit
+	backups         int                 // Number of previous versions of the local file to keep
+	limiter         *RateLimiter        // Limits the bandwidth of downloads, nil if unlimited
+	sharedLimiter   *RateLimiter        // Limits the bandwidth of the downloads of all lists of a Manager, nil if unlimited
+	decompress      []Decompressor      // Decompressors that are tried on downloaded content
+	compress        bool                // Whether to store the local file gzip-compressed
+	lowercase       bool                // Whether to lowercase records when adding them
+	domains         bool                // Whether records are hostnames that are stored without a trailing dot
+	sensitive       bool                // Whether matching is case-sensitive
+	unicode         bool                // Whether records are compared with Unicode case folding and NFC normalization
+	foldLanguage    language.Tag        // Language of the Unicode case folding, language.Und for language-independent folding
+	defaultHas      bool                // Whether fnHas is DefaultHasFunc, which allows batch lookups in a single pass
+	indexPrefix     bool                // Whether to maintain the prefix index
+	indexSuffix     bool                // Whether to maintain the suffix index
+	bloomRate       float64             // False-positive rate of the Bloom filter, 0 if disabled
+	shardCount      int                 // Number of shards of the records, 0 if they are kept in a single map
+	shardSeed       maphash.Seed        // Seed of the hash that assigns records to shards
+	sortRecords     bool                // Whether the records are kept in a sorted slice instead of a map
+	indexIP         bool                // Whether to maintain the network index
+	checksum        []byte              // Expected SHA-256 checksum of the downloaded content
+	checksumURL     string              // Location of a file containing the expected SHA-256 checksum
+	mu              *sync.RWMutex
+	loadMu          *sync.Mutex                 // Guards flight, held by Rollback while it restores a backup
+	flight          *loadCall                   // Load that is in progress, nil if there is none
+	initMu          *sync.Mutex                 // Serializes init, so the records of an older local file never replace newer ones
+	records         map[string]struct{}         // records stores the data from the list file
+	added           map[string]struct{}         // Records added with Add, they are persisted by Save
+	overridden      map[string]struct{}         // Records from the local overrides, to tell their source
+	expiries        map[string]time.Time        // Expiry times of the records added with AddWithTTL
+	nextExpiry      time.Time                   // Earliest of the expiries, zero if there are none
+	expiryTimer     *time.Timer                 // Removes the expired records at nextExpiry
+	shards          []*recordShard              // Shards of the records if enabled with WithShards, records and added are nil then
+	sorted          []string                    // Records if enabled with WithSortedStorage, records is nil then
+	published       *atomic.Pointer[recordSet]  // Records and indexes shared with Snapshots, nil if none shares them
+	edits           []recordEdit                // Changes of the records since they were published
+	copied          bool                        // Whether the published records have been copied for the changes
+	changes         *atomic.Uint64              // Number of changes of the records, tells whether the response of Handler is current
+	served          *atomic.Pointer[servedList] // Response of Handler, built for a number of changes
+	loaded          bool                        // Whether records have been loaded at least once
+	ready           chan struct{}               // Closed once records have been loaded for the first time
+	loadedState     fileState                   // State of the local file the records were parsed from
+	diffAdded       []string                    // Records added by the last reload
+	diffRemoved     []string                    // Records removed by the last reload
+	rejected        int                         // Number of lines the DataLineFunc rejected during the last load
+	malformed       int                         // Number of malformed entries skipped during the last load
+	downloaded      int                         // Number of records parsed from the local file during the last load, without overrides and added records
+	prefixes        *trie                       // Index of the lowercased records for HasPrefix, nil if disabled
+	suffixes        *trie                       // Index of the reversed lowercased records for HasSuffix, nil if disabled
+	networks        *ipTrie                     // Index of the networks for HasAddr, nil if disabled
+	bloom           *bloom                      // Bloom filter of the lowercased records for Has, nil if disabled
+	lastErr         error                       // Error of the most recent refresh, nil if it succeeded
+	invalid         int                         // Number of downloads rejected by a validator
+	stale           bool                        // Whether the records were loaded from an outdated local file
+	generation      int                         // Backup generation of the loaded local file, 0 for the latest download
+	strict          bool                        // Whether to fail if the download fails, even if a local file exists
+	allowEmpty      bool                        // Whether the constructor succeeds without records if the list can't be loaded
+	lazy            *lazyLoader                 // Loads the list on first use if enabled with WithLazyInit, nil otherwise
+	cancel          context.CancelFunc          // Stops the auto-refresh goroutine
+	done            chan struct{}               // Closed when the auto-refresh goroutine has exited
+	watchInterval   time.Duration               // Interval at which the local file is checked for changes, 0 if it isn't watched
+	watchCancel     context.CancelFunc          // Stops the goroutine that watches the local file
+	watchDone       chan struct{}               // Closed when the goroutine that watches the local file has exited
+	closed          bool                        // Whether Close has been called
+	closing         context.Context             // Canceled by Close to abort loads
+	closeLoads      context.CancelFunc          // Cancels closing
 }
 
 // Has checks if a value exists in the RemoteList
 func (rl *RemoteList) Has(value string) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	return rl.has(rl.query(value))
+}
+
+// has checks if the query term `value` exists, the caller must hold the read lock
+func (rl *RemoteList) has(value string) bool {
+	if rl.bloom != nil && !rl.bloom.has(rl.fold(value)) {
+		return false
+	}
+	if rl.shards != nil || rl.sortRecords {
+		return rl.hasRecord(rl.fold(value))
+	}
 	return rl.fnHas(rl.records, value)
 }
 
+// HasAny checks if at least one of the `values` exists in the RemoteList. It stops at the first value that exists.
+// HasAny() without values returns `false`.
+func (rl *RemoteList) HasAny(values ...string) bool {
+	return rl.hasValues(values, false)
+}
+
+// HasAll checks if all of the `values` exist in the RemoteList. It stops at the first value that doesn't exist.
+// HasAll() without values returns `true`.
+func (rl *RemoteList) HasAll(values ...string) bool {
+	return rl.hasValues(values, true)
+}
+
+// hasValues implements HasAny and HasAll. All values are checked under a single lock and
+// with the default HasFunc in a single pass over the records.
+func (rl *RemoteList) hasValues(values []string, all bool) bool {
+	rl.rlock()
+	defer rl.mu.RUnlock()
+
+	if !rl.defaultHas {
+		for _, v := range values {
+			if rl.has(rl.query(v)) != all {
+				return !all
+			}
+		}
+		return all
+	}
+
+	// The default HasFunc scans all records, so look at each record only once for all values
+	pending := map[string]struct{}{}
+	for _, v := range values {
+		term := strings.ToLower(rl.query(v))
+		if rl.bloom != nil && !rl.bloom.has(term) {
+			if all {
+				return false
+			}
+			continue
+		}
+		pending[term] = struct{}{}
+	}
+	if len(pending) == 0 {
+		return all
+	}
+	found := false
+	rl.eachRecord(func(rec string) bool {
+		term := strings.ToLower(rec)
+		if _, ok := pending[term]; !ok {
+			return true
+		}
+		if !all {
+			found = true
+			return false
+		}
+		delete(pending, term)
+		return len(pending) > 0
+	})
+	if all {
+		return len(pending) == 0
+	}
+	return found
+}
+
+// HasBatch checks which of the `values` exist in the RemoteList. The result maps each value,
+// as given by the caller, to whether it exists. All values are checked under a single lock and
+// with the default HasFunc in a single pass over the records.
+func (rl *RemoteList) HasBatch(values []string) map[string]bool {
+	res := make(map[string]bool, len(values))
+	rl.rlock()
+	defer rl.mu.RUnlock()
+
+	if !rl.defaultHas {
+		for _, v := range values {
+			res[v] = rl.has(rl.query(v))
+		}
+		return res
+	}
+
+	// Group the values by their lowercased form, so we only have to look at each record once
+	pending := map[string][]string{}
+	for _, v := range values {
+		res[v] = false
+		term := strings.ToLower(rl.query(v))
+		pending[term] = append(pending[term], v)
+	}
+	for rec := range rl.records {
+		if len(pending) == 0 {
+			break
+		}
+		term := strings.ToLower(rec)
+		for _, v := range pending[term] {
+			res[v] = true
+		}
+		delete(pending, term)
+	}
+	return res
+}
+
+// HasPrefix checks if any record in the RemoteList starts with `prefix`
+func (rl *RemoteList) HasPrefix(prefix string) bool {
+	prefix = rl.query(prefix)
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	if rl.prefixes != nil {
+		return rl.prefixes.hasPrefix(rl.fold(prefix))
+	}
+	if rl.sortRecords {
+		return sortedHasPrefix(rl.sorted, rl.fold(prefix))
+	}
+	found := false
+	rl.recordMaps(func(records map[string]struct{}) bool {
+		found = rl.fnHasPrefix(records, prefix)
+		return !found
+	})
+	return found
+}
+
+// HasSuffix checks if any record in the RemoteList ends with `suffix`
+func (rl *RemoteList) HasSuffix(suffix string) bool {
+	suffix = rl.query(suffix)
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	if rl.suffixes != nil {
+		return rl.suffixes.hasPrefix(reverse(rl.fold(suffix)))
+	}
+	if rl.sortRecords {
+		suffix = rl.fold(suffix)
+		return slices.ContainsFunc(rl.sorted, func(rec string) bool {
+			return strings.HasSuffix(rl.fold(rec), suffix)
+		})
+	}
+	found := false
+	rl.recordMaps(func(records map[string]struct{}) bool {
+		found = rl.fnHasSuffix(records, suffix)
+		return !found
+	})
+	return found
+}
+
 // Search searches for a value in the RemoteList and returns matching results
 func (rl *RemoteList) Search(value string) []string {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-	return rl.fnSearch(rl.records, value)
+	value = rl.query(value)
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	res := []string{}
+	if rl.sortRecords {
+		value = rl.fold(value)
+		for _, rec := range rl.sorted {
+			if strings.Contains(rl.fold(rec), value) {
+				res = append(res, rec)
+			}
+		}
+		return res
+	}
+	if rl.shards == nil {
+		return rl.fnSearch(rl.records, value)
+	}
+	rl.recordMaps(func(records map[string]struct{}) bool {
+		res = append(res, rl.fnSearch(records, value)...)
+		return true
+	})
+	sort.Strings(res)
+	return res
 }
 
 // Add adds a value to the RemoteList
 func (rl *RemoteList) Add(value string) {
+	value = rl.normalize(value)
+	if rl.shared(func() { rl.addRecord(value) }, value) {
+		return
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		return
+	}
+	delete(rl.expiries, value)
+	if rl.addRecord(value) {
+		rl.index(value)
+	}
+}
+
+// Remove removes a value from the RemoteList and reports whether it existed
+func (rl *RemoteList) Remove(value string) bool {
+	value = rl.normalize(value)
+	ok := false
+	if rl.shared(func() { ok = rl.removeRecord(value) }, value) {
+		return ok
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		return false
+	}
+	delete(rl.expiries, value)
+	ok = rl.removeRecord(value)
+	if ok {
+		rl.unindex(value)
+	}
+	return ok
+}
+
+// AddAll adds all `values` to the RemoteList under a single lock and returns the number of values
+// that were not in the RemoteList before
+func (rl *RemoteList) AddAll(values []string) int {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		return 0
+	}
+	n := 0
+	for _, value := range values {
+		value = rl.normalize(value)
+		delete(rl.expiries, value)
+		if rl.addRecord(value) {
+			rl.index(value)
+			n++
+		}
+	}
+	return n
+}
+
+// RemoveAll removes all `values` from the RemoteList under a single lock and returns the number of values
+// that existed
+func (rl *RemoteList) RemoveAll(values []string) int {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
+	if rl.closed {
+		return 0
+	}
+	n := 0
+	for _, value := range values {
+		value = rl.normalize(value)
+		delete(rl.expiries, value)
+		if rl.removeRecord(value) {
+			rl.unindex(value)
+			n++
+		}
+	}
+	return n
+}
+
+// Clear removes all values from the RemoteList
+func (rl *RemoteList) Clear() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		return
+	}
+	rl.records = map[string]struct{}{}
+	rl.added = map[string]struct{}{}
+	if rl.shards != nil {
+		rl.shards = rl.newShards(rl.shardCount, rl.records, rl.added)
+		rl.records, rl.added = nil, nil
+	}
+	if rl.sortRecords {
+		rl.records, rl.sorted = nil, []string{}
+	}
+	rl.expiries, rl.nextExpiry = nil, time.Time{}
+	rl.unpublish()
+	rl.changes.Add(1)
+	rl.prefixes = rl.newIndex(rl.indexPrefix, rl.records, false)
+	rl.suffixes = rl.newIndex(rl.indexSuffix, rl.records, true)
+	rl.networks = rl.newIPIndex(rl.records)
+	rl.bloom = rl.newBloomIndex(rl.records)
+}
+
+// newIndex creates an index of the case-folded `records` if `enabled` is `true`, otherwise it returns `nil`.
+// If `reversed` is `true`, the records are indexed reversed, which turns suffix into prefix queries.
+func (rl *RemoteList) newIndex(enabled bool, records map[string]struct{}, reversed bool) *trie {
+	if !enabled {
+		return nil
+	}
+	t := newTrie()
+	for rec := range records {
+		rec = rl.fold(rec)
+		if reversed {
+			rec = reverse(rec)
+		}
+		t.insert(rec)
+	}
+	return t
+}
+
+// index adds `value` to the indexes, the caller must hold the write lock
+func (rl *RemoteList) index(value string) {
+	value = rl.fold(value)
+	if rl.prefixes != nil {
+		rl.prefixes.insert(value)
+	}
+	if rl.suffixes != nil {
+		rl.suffixes.insert(reverse(value))
+	}
+	if rl.networks != nil {
+		if p, ok := parseNetwork(value); ok {
+			rl.networks.insert(p)
+		}
+	}
+	if rl.bloom != nil {
+		rl.bloom.add(value)
+	}
+}
+
+// unindex removes `value` from the indexes, the caller must hold the write lock.
+// Values can't be removed from the Bloom filter, it keeps them until it is rebuilt.
+func (rl *RemoteList) unindex(value string) {
+	value = rl.fold(value)
+	if rl.prefixes != nil {
+		rl.prefixes.remove(value)
+	}
+	if rl.suffixes != nil {
+		rl.suffixes.remove(reverse(value))
+	}
+	if rl.networks != nil {
+		if p, ok := parseNetwork(value); ok {
+			rl.networks.remove(p)
+		}
+	}
+}
+
+// normalize returns `value` in the form it is stored in the records
+func (rl *RemoteList) normalize(value string) string {
 	value = strings.TrimSpace(value)
-	rl.records[value] = struct{}{}
+	if rl.unicode {
+		value = norm.NFC.String(value)
+	}
+	if rl.lowercase {
+		value = rl.fold(value)
+	}
+	if rl.domains {
+		value = strings.TrimSuffix(value, ".")
+	}
+	if rl.fnNormalize != nil {
+		value = rl.fnNormalize(value)
+	}
+	return value
+}
+
+// query returns the query term `value` normalized like the records, except for case which is left to the configured functions
+func (rl *RemoteList) query(value string) string {
+	if rl.unicode {
+		value = norm.NFC.String(value)
+	}
+	if rl.domains {
+		value = strings.TrimSuffix(value, ".")
+	}
+	if rl.fnNormalize != nil {
+		value = rl.fnNormalize(value)
+	}
+	return value
+}
+
+// fold returns `value` in the form it is compared in: lowercased (or case-folded with WithUnicodeFolding)
+// unless matching is case-sensitive
+func (rl *RemoteList) fold(value string) string {
+	if rl.sensitive {
+		return value
+	}
+	if rl.unicode {
+		return rl.unicodeFold(value)
+	}
+	return strings.ToLower(value)
+}
+
+// Len returns the number of records in the RemoteList
+func (rl *RemoteList) Len() int {
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	return rl.recordCount()
 }
 
 // List returns the data stored in the RemoteList as a sorted string slice
 func (rl *RemoteList) List() []string {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
+	rl.rlock()
+	defer rl.mu.RUnlock()
 	res := []string{}
-	for rec := range rl.records {
+	rl.eachRecord(func(rec string) bool {
 		res = append(res, rec)
-	}
+		return true
+	})
 	sort.Strings(res)
 	return res
 }
 
-// download downloads the list from the remote location if necessary
-func (rl *RemoteList) download() error {
+// Refresh downloads the list again if the local file is older than maxAge (or always if `force` is `true`)
+// and replaces the records with the freshly parsed ones. Records that are no longer present in the list
+// are dropped, records added with Add are kept (see ListBySource). Readers keep using the old records until the new set is ready.
+func (rl *RemoteList) Refresh(force bool) error {
+	return rl.RefreshContext(context.Background(), force)
+}
+
+// RefreshContext is like Refresh but aborts the download when `ctx` is canceled.
+func (rl *RemoteList) RefreshContext(ctx context.Context, force bool) error {
+	return rl.load(ctx, force)
+}
+
+// Diff returns the sorted records that have been added and removed by the most recent reload,
+// e.g. by Refresh. Both are empty if the list has not been reloaded yet or the reload didn't change anything.
+// Only the differences are kept, not the previous records.
+func (rl *RemoteList) Diff() (added, removed []string) {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	added = append([]string{}, rl.diffAdded...)
+	removed = append([]string{}, rl.diffRemoved...)
+	return added, removed
+}
+
+// LastError returns the error of the most recent refresh or `nil` if it succeeded.
+// If the RemoteList fell back to the local file, this returns the download error.
+func (rl *RemoteList) LastError() error {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.lastErr
+}
+
+// Source returns the remote location (fileRemote or one of the mirrors) from which the list
+// was downloaded the last time. It is empty if the list has not been downloaded by this RemoteList.
+func (rl *RemoteList) Source() string {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.source
+}
+
+// IsStale returns `true` if the most recent refresh could not download the list and the records are therefore
+// based on an outdated local file, or if the local file the records were loaded from is older than maxAge,
+// e.g. because nothing refreshed the list. With RefreshAlways and RefreshNever only the former applies.
+func (rl *RemoteList) IsStale() bool {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.isStale()
+}
+
+// isStale does the work of IsStale, the caller must hold the lock
+func (rl *RemoteList) isStale() bool {
+	if rl.stale {
+		return true
+	}
+	modTime := rl.loadedState.modTime
+	if modTime.IsZero() || rl.refreshAge == RefreshAlways || rl.refreshAge == RefreshNever {
+		return false
+	}
+	return time.Since(modTime) > rl.refreshAge
+}
+
+// LastRefresh returns the time of the last refresh that loaded up-to-date records, i.e. that downloaded the
+// list or found the local file to be younger than maxAge. It is the zero time if there was none.
+func (rl *RemoteList) LastRefresh() time.Time {
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	return rl.lastRefresh
+}
+
+// StartAutoRefresh starts a goroutine that calls Refresh every `interval`, so the list is downloaded
+// again once the local file is older than maxAge. Failed refreshes keep the current records,
+// use LastError to retrieve the error. Calling StartAutoRefresh again replaces the running goroutine.
+// An `interval` <= 0 is invalid, it is logged and nothing is started.
+func (rl *RemoteList) StartAutoRefresh(interval time.Duration) {
+	if interval <= 0 {
+		rl.log(slog.LevelWarn, "invalid auto-refresh interval, not starting", "interval", interval)
+		return
+	}
+	rl.stopAutoRefresh()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	rl.mu.Lock()
+	if rl.closed {
+		rl.mu.Unlock()
+		cancel()
+		return
+	}
+	rl.cancel, rl.done = cancel, done
+	rl.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = rl.RefreshContext(ctx, false)
+			}
+		}
+	}()
+}
+
+// Stop stops the auto-refresh goroutine and the watcher of the local file (if any) and waits for them to exit.
+// A download that is in progress is aborted.
+func (rl *RemoteList) Stop() {
+	rl.stopAutoRefresh()
+	rl.stopWatch()
+}
+
+// stopAutoRefresh stops the auto-refresh goroutine (if any) and waits for it to exit
+func (rl *RemoteList) stopAutoRefresh() {
+	rl.mu.Lock()
+	cancel, done := rl.cancel, rl.done
+	rl.cancel, rl.done = nil, nil
+	rl.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// start loads the list for the first time (unless it is loaded lazily) and starts watching the local file if enabled.
+// If the list can't be loaded, it fails unless empty starts are allowed (see WithAllowEmptyStart).
+func (rl *RemoteList) start(ctx context.Context) error {
+	// Lists created with WithLazyInit are loaded on first use
+	if rl.lazy == nil {
+		if err := rl.load(ctx, false); err != nil {
+			if !rl.allowEmpty {
+				_ = rl.removeEphemeral()
+				return err
+			}
+			rl.log(slog.LevelWarn, "list could not be loaded, starting without records", "error", err)
+		}
+	}
+	rl.startWatch()
+	return nil
+}
+
+// loadCall is a load that is in progress, other callers wait for it to finish and share its result
+type loadCall struct {
+	done chan struct{}
+	err  error
+}
+
+// load downloads the list if necessary and parses it. If the download fails but a local file exists,
+// the local file is used and the records are marked as stale (unless the RemoteList is strict).
+// Concurrent calls are coalesced: while a load is in progress, other callers wait for it
+// (or for their `ctx` to be canceled) and return its result instead of downloading again.
+func (rl *RemoteList) load(ctx context.Context, force bool) error {
+	if rl.isClosed() {
+		return ErrClosed
+	}
+	if rl.static {
+		return nil
+	}
+	rl.loadMu.Lock()
+	if c := rl.flight; c != nil {
+		rl.loadMu.Unlock()
+		select {
+		case <-c.done:
+			return c.err
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
+	}
+	c := &loadCall{done: make(chan struct{})}
+	rl.flight = c
+	rl.loadMu.Unlock()
+
+	ctx, cancel := rl.closeable(ctx)
+	c.err = rl.loadOnce(ctx, force)
+	cancel()
+
+	rl.loadMu.Lock()
+	rl.flight = nil
+	rl.loadMu.Unlock()
+	close(c.done)
+	return c.err
+}
+
+// loadOnce does the work of load
+func (rl *RemoteList) loadOnce(ctx context.Context, force bool) error {
+	errDownload := rl.download(ctx, force)
+
+	rl.mu.RLock()
+	loaded := rl.loaded
+	rl.mu.RUnlock()
+
+	var err error
+	if errDownload != nil {
+		if _, errStat := rl.storage.Stat(rl.fileLocal); rl.strict || errStat != nil {
+			err = errDownload
+		} else if loaded && errors.Is(errDownload, ErrValidation) {
+			// The local file has not been replaced, so the current records are still up to date
+			rl.log(slog.LevelWarn, "list download rejected, keeping current records", "error", errDownload)
+			err = errDownload
+		} else {
+			rl.log(slog.LevelWarn, "list download failed, using outdated local file", "error", errDownload)
+		}
+	}
+	// A list that is kept in memory only has been parsed while it was downloaded
+	if err == nil && !rl.memoryOnly {
+		err = rl.init()
+	}
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.stale = errDownload != nil || err != nil
+	if !rl.stale {
+		rl.lastRefresh = time.Now()
+	}
+	rl.lastErr = err
+	if err == nil {
+		rl.lastErr = errDownload
+	}
+	if errors.Is(errDownload, ErrValidation) {
+		rl.invalid++
+	}
+	return err
+}
+
+// download downloads the list from the remote location if necessary (or always if `force` is `true`).
+// The mirrors are tried in order until one of them succeeds.
+// The local file is only written once the complete response has been read.
+func (rl *RemoteList) download(ctx context.Context, force bool) error {
 	// Check if download is needed based on file's last modification time
+	rl.mu.RLock()
+	maxAge := rl.refreshAge
+	rl.mu.RUnlock()
 	needsDownload := true
-	fileInfo, err := os.Stat(rl.fileLocal)
+	fileInfo, err := rl.storage.Stat(rl.fileLocal)
 	fileExists := err == nil
-	if fileExists && time.Since(fileInfo.ModTime()) < rl.maxAge {
+	if !fileExists {
+		fileInfo = nil
+	}
+	if !force && fileExists && time.Since(fileInfo.ModTime()) < maxAge {
 		needsDownload = false
 	}
 
-	// Perform download if necessary
-	if needsDownload {
-		resp, err := http.Get(rl.fileRemote)
-		if err != nil {
-			return fmt.Errorf("list download failed: %s", err.Error())
+	// Rewrite the local file if its compression does not match the configuration
+	rewrite := fileExists && maxAge != RefreshNever && isGzipFile(rl.storage, rl.fileLocal) != rl.compress
+	if rewrite {
+		needsDownload = true
+	}
+
+	if !needsDownload {
+		return nil
+	}
+	if err := rl.createDir(); err != nil {
+		return err
+	}
+
+	// Another process may download the list at the same time, wait for it and use its download
+	unlock, err := rl.lockLocal(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if fi, err := rl.storage.Stat(rl.fileLocal); err == nil && !rewrite && (!fileExists || !fi.ModTime().Equal(fileInfo.ModTime())) {
+		rl.log(slog.LevelDebug, "list downloaded by another process", "remote", rl.fileRemote)
+		return nil
+	}
+
+	// Perform download, falling back to the mirrors
+	remotes := append([]string{rl.fileRemote}, rl.mirrors...)
+	errs := []error{}
+	for _, remote := range remotes {
+		start := time.Now()
+		rl.log(slog.LevelDebug, "downloading list", "remote", remote)
+		err := rl.downloadRetry(ctx, remote, fileInfo, rewrite)
+		if err == nil {
+			rl.log(slog.LevelInfo, "list downloaded", "remote", remote, "duration", time.Since(start))
+			rl.mu.Lock()
+			rl.source = remote
+			rl.lastDownload = time.Now()
+			rl.lastDuration = rl.lastDownload.Sub(start)
+			rl.refreshAge = rl.jitterMaxAge()
+			rl.mu.Unlock()
+			return nil
+		}
+		rl.log(slog.LevelWarn, "list download failed", "remote", remote, "error", err)
+		if len(remotes) == 1 {
+			return err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", remote, err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// newRequest creates a GET request for `remote` with the configured headers and request modifier applied
+func (rl *RemoteList) newRequest(ctx context.Context, remote string) (*http.Request, error) {
+	return rl.newRequestMethod(ctx, http.MethodGet, remote)
+}
+
+// newRequestMethod is like newRequest but creates a request with the given `method`
+func (rl *RemoteList) newRequestMethod(ctx context.Context, method, remote string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, remote, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", rl.userAgent)
+	for key, values := range rl.headers {
+		req.Header.Del(key)
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	if rl.fnRequest != nil {
+		rl.callback(func() { rl.fnRequest(req) })
+	}
+	return req, nil
+}
+
+// fetchHTTP requests the list from `remote` with the configured HTTP client. If `conditional` is `true`,
+// the list is only requested if it changed since the last download. If it did not, the age of the local file
+// is reset and the response is `nil`. Otherwise the caller must close the body of the response.
+func (rl *RemoteList) fetchHTTP(ctx context.Context, remote string, conditional bool) (*http.Response, error) {
+	req, err := rl.newRequest(ctx, remote)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	// Resume the partial download of a previous attempt, or only ask for the list if it changed since the last download
+	offset, validator := int64(0), ""
+	if rl.resume {
+		offset, validator = rl.partialOffset()
+	}
+	if offset > 0 {
+		rl.log(slog.LevelDebug, "resuming list download", "remote", remote, "offset", offset)
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	} else if conditional {
+		meta := readMetadata(rl.storage, rl.fileLocal)
+		if rl.headCheck && rl.unchanged(ctx, remote, meta) {
+			return nil, rl.notModified(remote)
+		}
+		if meta.ETag != "" {
+			req.Header.Set("If-None-Match", meta.ETag)
 		}
-		defer resp.Body.Close()
+		if meta.LastModified != "" {
+			req.Header.Set("If-Modified-Since", meta.LastModified)
+		}
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return fmt.Errorf("list download failed with status code: %d", resp.StatusCode)
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		if errors.Is(context.Cause(ctx), ErrDownloadTimeout) {
+			return nil, fmt.Errorf("%w after %s", ErrDownloadTimeout, rl.timeout)
 		}
+		return nil, fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+	}
+
+	// The list did not change, reset its age so we don't ask again before maxAge has passed
+	if resp.StatusCode == http.StatusNotModified && conditional {
+		resp.Body.Close()
+		return nil, rl.notModified(remote)
+	}
+
+	// The partial download can't be resumed, start over
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		resp.Body.Close()
+		rl.removePartial()
+		return rl.fetchHTTP(ctx, remote, conditional)
+	}
+
+	if resp.StatusCode != http.StatusOK && (resp.StatusCode != http.StatusPartialContent || offset == 0) {
+		resp.Body.Close()
+		errStatus := &StatusError{StatusCode: resp.StatusCode}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			errStatus.RetryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+		}
+		return nil, errStatus
+	}
+
+	if rl.resume {
+		return rl.spool(ctx, resp, offset)
+	}
 
-		// Read response body and write to local file
-		data, err := io.ReadAll(resp.Body)
+	if rl.maxSize > 0 && resp.ContentLength > rl.maxSize {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrDownloadTooLarge, resp.ContentLength, rl.maxSize)
+	}
+	return resp, nil
+}
+
+// notModified resets the age of the local file after `remote` reported that the list did not change,
+// so we don't ask again before maxAge has passed
+func (rl *RemoteList) notModified(remote string) error {
+	rl.log(slog.LevelDebug, "list not modified", "remote", remote)
+	if err := rl.storage.Touch(rl.fileLocal, time.Now()); err != nil {
+		return fmt.Errorf("%w, could not update modification time: %w", ErrWriteLocal, err)
+	}
+	return nil
+}
+
+// downloadFrom downloads the list from `remote` and writes it to the local file.
+// `fileInfo` describes the current local file and is `nil` if there is none.
+// If `rewrite` is `true`, the list is downloaded even if it did not change.
+//
+// The response is streamed through decompression and compression into a temporary file
+// which replaces the local file once the download succeeded. Only a DataFilterFunc
+// requires the (decompressed) content to be held in memory.
+func (rl *RemoteList) downloadFrom(ctx context.Context, remote string, fileInfo os.FileInfo, rewrite bool) error {
+	fileExists := fileInfo != nil
+
+	// The timeout also covers reading the response body
+	if rl.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeoutCause(ctx, rl.timeout, ErrDownloadTimeout)
+		defer cancel()
+	}
+
+	// Retrieve the list with the configured Fetcher (or from the local path it refers to),
+	// only HTTP downloads can be skipped if the list did not change
+	fetcher := rl.fetcher
+	if _, ok := localSource(remote); ok && fetcher == nil {
+		fetcher = fileFetcher{}
+	}
+	var resp *http.Response
+	var rc io.ReadCloser
+	var err error
+	if fetcher != nil {
+		rc, err = fetcher.Fetch(ctx, remote)
 		if err != nil {
-			return fmt.Errorf("list download failed, could not read response: %s", err.Error())
+			if errors.Is(context.Cause(ctx), ErrDownloadTimeout) {
+				return fmt.Errorf("%w after %s", ErrDownloadTimeout, rl.timeout)
+			}
+			if errors.Is(err, ErrDownloadFailed) {
+				return err
+			}
+			return fmt.Errorf("%w: %w", ErrDownloadFailed, err)
+		}
+	} else {
+		resp, err = rl.fetchHTTP(ctx, remote, fileExists && !rewrite)
+		if err != nil || resp == nil {
+			return err
 		}
+		rc = resp.Body
+		if rl.resume {
+			// The partial download is complete, whatever happens to it now it must not be resumed
+			defer rl.removePartial()
+		}
+	}
+	defer rc.Close()
+
+	// Get the expected checksum once the list has changed, so we know whether to keep what we download
+	checksum, err := rl.expectedChecksum(ctx)
+	if err != nil {
+		return err
+	}
 
-		// Optionally preprocess data before writing to file
-		permissions := os.FileMode(0644)
-		if fileExists {
-			permissions = fileInfo.Mode().Perm()
+	// Hash the response as published (if we have a checksum to compare with) and decompress it if it is compressed
+	response := io.Reader(rc)
+	if !rl.resume || resp == nil {
+		total := int64(-1)
+		if resp != nil {
+			total = resp.ContentLength
 		}
+		response = rl.progress(rl.rateLimit(ctx, rc), 0, total)
+	}
+	body := &errReader{r: response}
+	raw := limitReader(body, rl.maxSize)
+	hash := sha256.New()
+	if checksum != nil {
+		raw = io.TeeReader(raw, hash)
+	}
+	buf := bufio.NewReader(raw)
+	head, _ := buf.Peek(512)
+	src, err := decompressReader(rl.decompress, resp, head, buf)
+	if err != nil {
+		return fmt.Errorf("%w, could not decompress response: %w", ErrDownloadFailed, err)
+	}
+	defer src.Close()
+	in := &errReader{r: limitReader(src, rl.maxSize)}
 
-		if rl.fnDataFiler == nil {
-			err = os.WriteFile(rl.fileLocal, data, permissions)
-		} else {
-			err = os.WriteFile(rl.fileLocal, []byte(rl.fnDataFiler(string(data))), permissions)
+	permissions := rl.localMode()
+
+	err = rl.storage.Write(rl.fileLocal, permissions, func(w io.Writer) error {
+		// A list that is kept in memory only is parsed instead of being stored, the local file stays empty
+		// and only tells its age
+		var load func() error
+		if rl.memoryOnly {
+			var pw *io.PipeWriter
+			pw, load = rl.loading()
+			defer pw.CloseWithError(ErrDownloadFailed)
+			w = pw
+		}
+
+		// Optionally compress data before writing to file
+		var gz *gzip.Writer
+		if rl.compress {
+			gz = gzip.NewWriter(w)
+			w = gz
+		}
+
+		// Optionally parse the data while writing it, so it can be validated before it replaces the local file
+		var validate func() error
+		if rl.fnValidate != nil {
+			var pw *io.PipeWriter
+			pw, validate = rl.validating()
+			defer pw.Close()
+			w = io.MultiWriter(w, pw)
 		}
 
+		// Optionally filter data before writing to file
+		var err error
+		if rl.fnStreamFilter == nil {
+			_, err = io.Copy(w, in)
+		} else {
+			rl.callback(func() { err = rl.fnStreamFilter(w, in) })
+		}
 		if err != nil {
-			return fmt.Errorf("list download failed, could not write data: %s", err.Error())
+			return err
+		}
+
+		// Read what the decompressor left over, so the checksum covers the complete response
+		if checksum != nil {
+			if _, err := io.Copy(io.Discard, buf); err != nil {
+				return err
+			}
+			if err := verifyChecksum(checksum, hash.Sum(nil)); err != nil {
+				return err
+			}
+		}
+
+		if gz != nil {
+			if err := gz.Close(); err != nil {
+				return err
+			}
+		}
+
+		if validate != nil {
+			if err := validate(); err != nil {
+				return err
+			}
+		}
+
+		if load != nil {
+			if err := load(); err != nil {
+				return err
+			}
 		}
+
+		// Keep the current version now that the new one is complete, it is replaced once we return
+		return rl.rotateBackups()
+	})
+
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrChecksumMismatch), errors.Is(err, ErrDownloadTooLarge), errors.Is(err, ErrValidation), errors.Is(err, ErrReadLocal):
+			return err
+		case errors.Is(context.Cause(ctx), ErrDownloadTimeout):
+			return fmt.Errorf("%w after %s", ErrDownloadTimeout, rl.timeout)
+		case body.err != nil || in.err != nil:
+			return fmt.Errorf("%w, could not read response: %w", ErrDownloadFailed, err)
+		}
+		return fmt.Errorf("%w: %w", ErrWriteLocal, err)
 	}
+
+	rl.mu.Lock()
+	rl.generation = 0
+	rl.mu.Unlock()
+
+	// Remember the validators for the next download, failing to do so only costs a full download
+	meta := metadata{}
+	if resp != nil {
+		meta = metadata{
+			ETag:          resp.Header.Get("ETag"),
+			LastModified:  resp.Header.Get("Last-Modified"),
+			ContentLength: max(resp.ContentLength, 0),
+		}
+	}
+	_ = writeMetadata(rl.storage, rl.fileLocal, meta, permissions)
+
 	return nil
 }
 
-// init initializes the RemoteList by reading data from the local file
+// init initializes the RemoteList by reading data from the local file.
+// The records are parsed into a new map which then replaces the current one, so records removed
+// from the file disappear on reload. If reading or parsing fails, the current records are kept.
 func (rl *RemoteList) init() error {
-	// Read file data
-	fileData, err := os.ReadFile(rl.fileLocal)
+	return rl.initFrom(nil)
+}
+
+// initFrom is like init but reads the list from `src` instead of the local file if `src` isn't `nil`,
+// i.e. the download of a list that is kept in memory only (see loading)
+func (rl *RemoteList) initFrom(src io.Reader) error {
+	rl.initMu.Lock()
+	defer rl.initMu.Unlock()
+
+	// Remember which version of the local file is parsed, so the watcher only reloads it if it changes afterwards
+	state, _ := rl.localState()
+
+	// Stream the local file and the local overrides and split them into raw records, stopping at the first error.
+	// A missing override file just means there are no overrides.
+	var errRead error
+	malformed := 0
+	readFile := func(file string, override bool, yield func(string) bool) bool {
+		var f io.ReadCloser
+		var err error
+		if src != nil && !override {
+			f, err = readLocal(io.NopCloser(src))
+		} else {
+			f, err = openLocal(rl.storage, file)
+		}
+		if override && errors.Is(err, os.ErrNotExist) {
+			return true
+		}
+		if err != nil {
+			if override {
+				errRead = fmt.Errorf("%w, could not read local overrides: %w", ErrReadLocal, err)
+			} else {
+				errRead = fmt.Errorf("%w: %w", ErrReadLocal, err)
+			}
+			return false
+		}
+		defer f.Close()
+		for value, err := range rl.split(f, &malformed) {
+			if err != nil {
+				errRead = fmt.Errorf("%w: %w", ErrReadLocal, err)
+				return false
+			}
+			if !yield(value) {
+				return false
+			}
+		}
+		return true
+	}
+	values := func(yield func(string) bool) {
+		if !readFile(rl.fileLocal, false, yield) {
+			return
+		}
+		for _, file := range rl.overrides {
+			if !readFile(file, true, yield) {
+				return
+			}
+		}
+	}
+
+	// Let an alternative storage parse the data if there is one
+	if rl.store != nil {
+		commit, count, rejected := rl.store.parse(values)
+		if errRead != nil {
+			return errRead
+		}
+		commit()
+		rl.mu.Lock()
+		if !rl.loaded {
+			close(rl.ready)
+		}
+		rl.loaded = true
+		rl.rejected, rl.malformed, rl.downloaded = rejected, malformed, count
+		rl.loadedState = state
+		rl.mu.Unlock()
+		rl.log(slog.LevelInfo, "list loaded", "records", count, "rejected_lines", rejected, "malformed", malformed)
+		return nil
+	}
+
+	// Process each line of data and populate records map, the overrides are kept apart to track their source
+	records := map[string]struct{}{}
+	rejected := rl.parse(func(yield func(string) bool) { readFile(rl.fileLocal, false, yield) }, records)
+	downloaded := len(records)
+	overridden := map[string]struct{}{}
+	for _, file := range rl.overrides {
+		if errRead != nil {
+			break
+		}
+		rejected += rl.parse(func(yield func(string) bool) { readFile(file, true, yield) }, overridden)
+	}
+	if errRead != nil {
+		return errRead
+	}
+	for rec := range overridden {
+		records[rec] = struct{}{}
+	}
+
+	// Only keep valid networks if the list is an IP list
+	malformed += rl.dropInvalidNetworks(records, overridden)
+
+	// Merge the records persisted by Save
+	added, err := readAdded(rl.storage, rl.fileLocal)
 	if err != nil {
-		return fmt.Errorf("error reading local file: %s", err)
+		return fmt.Errorf("%w, could not read added records: %w", ErrReadLocal, err)
+	}
+	for rec := range added {
+		records[rec] = struct{}{}
+	}
+
+	rl.setRecords(records, added, overridden)
+	rl.mu.Lock()
+	rl.rejected, rl.malformed, rl.downloaded = rejected, malformed, downloaded
+	rl.loadedState = state
+	rl.mu.Unlock()
+	rl.log(slog.LevelInfo, "list loaded", "records", len(records), "rejected_lines", rejected, "malformed", malformed)
+	return nil
+}
+
+// loading starts loading the records from what is written to the returned pipe, e.g. a download that is parsed
+// without being stored. The returned function closes the pipe and waits for the records to be swapped in.
+// If the caller closes the pipe with an error instead, the current records are kept.
+func (rl *RemoteList) loading() (*io.PipeWriter, func() error) {
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := rl.initFrom(pr)
+		pr.CloseWithError(err)
+		done <- err
+	}()
+
+	return pw, func() error {
+		pw.Close()
+		return <-done
+	}
+}
+
+// setRecords builds the indexes for `records` and swaps them in together with the records that have been
+// persisted by Save (`added`) and the records from the local overrides (`overridden`). The records that have been
+// added with Add and AddWithTTL are kept. If the records changed, the OnChangeFunc is called.
+// Readers see either the previous or the new records, never a mix, and aren't blocked while the new ones are built.
+func (rl *RemoteList) setRecords(records, added, overridden map[string]struct{}) {
+	// Everything is built without holding the lock, readers only wait for the swap
+	prefixes := rl.newIndex(rl.indexPrefix, records, false)
+	suffixes := rl.newIndex(rl.indexSuffix, records, true)
+	networks := rl.newIPIndex(records)
+	bloom := rl.newBloomIndex(records)
+	var shards []*recordShard
+	if rl.shardCount > 0 {
+		shards = rl.newShards(rl.shardCount, records, added)
+	}
+	var sorted []string
+	if rl.sortRecords {
+		sorted = newSorted(records)
+	}
+	rl.mu.Lock()
+	previous, previousShards, previousSorted, loaded := rl.records, rl.shards, rl.sorted, rl.loaded
+	kept := rl.addedRecords()
+	rl.records = records
+	rl.added = added
+	if shards != nil {
+		rl.records, rl.added, rl.shards = nil, nil, shards
+	}
+	if rl.sortRecords {
+		rl.records, rl.sorted = nil, sorted
+	}
+	rl.prefixes = prefixes
+	rl.suffixes = suffixes
+	rl.networks = networks
+	rl.bloom = bloom
+	rl.overridden = overridden
+	rl.unpublish()
+	rl.changes.Add(1)
+	if !loaded {
+		close(rl.ready)
+	}
+	rl.loaded = true
+	rl.keepAdded(kept, records)
+	rl.mu.Unlock()
+
+	if !loaded {
+		return
+	}
+	if previousShards != nil {
+		previous = mergeShards(previousShards)
+	}
+	if rl.sortRecords {
+		previous = make(map[string]struct{}, len(previousSorted))
+		for _, rec := range previousSorted {
+			previous[rec] = struct{}{}
+		}
 	}
 
-	// Process each line of data and populate records map
-	for _, line := range strings.Split(string(fileData), "\n") {
+	// The previous records are no longer reachable by others, only the new ones need the lock
+	rl.mu.RLock()
+	addedRecords, removedRecords := diffRecords(previous, records)
+	rl.mu.RUnlock()
+
+	rl.mu.Lock()
+	rl.diffAdded, rl.diffRemoved = addedRecords, removedRecords
+	rl.mu.Unlock()
+
+	if rl.fnChange != nil && (len(addedRecords) > 0 || len(removedRecords) > 0) {
+		rl.callback(func() { rl.fnChange(addedRecords, removedRecords) })
+	}
+}
+
+// parse runs each of the `lines` through the DataLineFunc and adds the resulting records to `records`
+// It returns the number of lines the DataLineFunc rejected.
+func (rl *RemoteList) parse(lines iter.Seq[string], records map[string]struct{}) (rejected int) {
+	for line := range lines {
 		if rl.fnDataLine != nil {
 			if str, ok := rl.fnDataLine(line); ok {
-				rl.Add(str)
+				records[rl.normalize(str)] = struct{}{}
+			} else {
+				rejected++
 			}
 		}
 	}
-
-	return nil
+	return rejected
 }
 
-// New creates a new RemoteList instance with the specified parameters
+// New creates a new RemoteList instance with the specified parameters.
+// Functions that are `nil` are replaced by the default functions.
 func New(
 	fileLocal, fileRemote string,
 	maxAge time.Duration,
@@ -207,49 +1537,246 @@ func New(
 	fnSearch SearchFunc,
 	fnDataFilter DataFilterFunc,
 	fnDataLine DataLineFunc,
+	opts ...Option,
+) (*RemoteList, error) {
+	return NewWithContext(context.Background(), fileLocal, fileRemote, maxAge, fnHas, fnHasPrefix, fnHasSuffix, fnSearch, fnDataFilter, fnDataLine, opts...)
+}
+
+// NewWithContext is like New but aborts the initial download when `ctx` is canceled
+func NewWithContext(
+	ctx context.Context,
+	fileLocal, fileRemote string,
+	maxAge time.Duration,
+	fnHas, fnHasPrefix, fnHasSuffix HasFunc,
+	fnSearch SearchFunc,
+	fnDataFilter DataFilterFunc,
+	fnDataLine DataLineFunc,
+	opts ...Option,
 ) (*RemoteList, error) {
+	opts = append([]Option{
+		WithMaxAge(maxAge),
+		WithHasFunc(fnHas),
+		WithHasPrefixFunc(fnHasPrefix),
+		WithHasSuffixFunc(fnHasSuffix),
+		WithSearchFunc(fnSearch),
+		WithDataFilter(fnDataFilter),
+		WithDataLine(fnDataLine),
+	}, opts...)
+	return NewWithOptionsContext(ctx, fileLocal, fileRemote, opts...)
+}
+
+// NewSimple creates a new RemoteList instance that uses the default functions
+func NewSimple(fileLocal, fileRemote string, maxAge time.Duration, opts ...Option) (*RemoteList, error) {
+	return New(fileLocal, fileRemote, maxAge, nil, nil, nil, nil, nil, nil, opts...)
+}
+
+// NewCached creates a new RemoteList instance that uses the default functions and keeps the local file in the
+// user's cache directory (see os.UserCacheDir), e.g. `~/.cache/remotelist/example.com_list.txt-1a2b3c4d5e6f7a8b`.
+// The file name is derived from `fileRemote`, Stats.LocalPath returns it.
+func NewCached(fileRemote string, maxAge time.Duration, opts ...Option) (*RemoteList, error) {
+	fileLocal, err := cachePath(fileRemote)
+	if err != nil {
+		return nil, err
+	}
+	return NewSimple(fileLocal, fileRemote, maxAge, opts...)
+}
+
+// NewWithOptions creates a new RemoteList instance that downloads `fileRemote` to `fileLocal`
+// and is configured by the given options. Unless configured otherwise, the default functions
+// are used and the list is downloaded again once the local file is older than `DefaultMaxAge`.
+// `fileRemote` may also be a `file://` URL or an absolute path, the file is then copied to `fileLocal`.
+// If `fileLocal` is empty, only the records are kept in memory (see WithoutLocalFile).
+func NewWithOptions(fileLocal, fileRemote string, opts ...Option) (*RemoteList, error) {
+	return NewWithOptionsContext(context.Background(), fileLocal, fileRemote, opts...)
+}
+
+// NewWithOptionsContext is like NewWithOptions but aborts the initial download when `ctx` is canceled
+func NewWithOptionsContext(ctx context.Context, fileLocal, fileRemote string, opts ...Option) (*RemoteList, error) {
+	rl, err := newRemoteList(fileLocal, fileRemote, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Download and initialize the list
+	if err := rl.start(ctx); err != nil {
+		return nil, err
+	}
+
+	return rl, nil
+}
+
+// newRemoteList creates a new RemoteList instance configured by the given options without loading any records
+func newRemoteList(fileLocal, fileRemote string, opts ...Option) (*RemoteList, error) {
 	// Initialize RemoteList struct
 	rl := &RemoteList{
-		mu:          &sync.Mutex{},
-		maxAge:      maxAge,
-		fileLocal:   fileLocal,
-		fileRemote:  fileRemote,
-		fnSearch:    fnSearch,
-		fnHas:       fnHas,
-		fnHasPrefix: fnHasPrefix,
-		fnHasSuffix: fnHasSuffix,
-		fnDataFiler: fnDataFilter,
-		fnDataLine:  fnDataLine,
-		records:     map[string]struct{}{},
+		mu:         &sync.RWMutex{},
+		loadMu:     &sync.Mutex{},
+		initMu:     &sync.Mutex{},
+		published:  &atomic.Pointer[recordSet]{},
+		changes:    &atomic.Uint64{},
+		served:     &atomic.Pointer[servedList]{},
+		ready:      make(chan struct{}),
+		maxAge:     DefaultMaxAge,
+		dirMode:    DefaultDirMode,
+		fileLocal:  fileLocal,
+		fileRemote: fileRemote,
+		client:     DefaultHTTPClient,
+		decompress: []Decompressor{GzipDecompressor},
+		records:    map[string]struct{}{},
+		added:      map[string]struct{}{},
+	}
+	rl.closing, rl.closeLoads = context.WithCancel(context.Background())
+
+	// Apply options
+	for _, opt := range opts {
+		if err := opt(rl); err != nil {
+			return nil, err
+		}
+	}
+
+	if rl.sensitive && rl.lowercase {
+		return nil, fmt.Errorf("case-sensitive matching can't be combined with lowercased records")
+	}
+
+	if rl.bloomRate > 0 && rl.fnHas != nil {
+		return nil, fmt.Errorf("the Bloom filter can't be combined with a custom HasFunc")
+	}
+
+	if rl.shardCount > 0 {
+		switch {
+		case rl.fnHas != nil:
+			return nil, fmt.Errorf("sharded records can't be combined with a custom HasFunc")
+		case !rl.lowercase && !rl.sensitive:
+			return nil, fmt.Errorf("sharded records require WithFastHas or WithCaseSensitive")
+		case rl.indexPrefix || rl.indexSuffix || rl.indexIP || rl.bloomRate > 0:
+			return nil, fmt.Errorf("sharded records can't be combined with indexes or the Bloom filter")
+		}
+		rl.shardSeed = maphash.MakeSeed()
+		rl.shards = rl.newShards(rl.shardCount, rl.records, rl.added)
+		rl.records, rl.added = nil, nil
+	}
+
+	if rl.sortRecords {
+		switch {
+		case rl.fnHas != nil || rl.fnHasPrefix != nil || rl.fnHasSuffix != nil || rl.fnSearch != nil:
+			return nil, fmt.Errorf("sorted records can't be combined with custom functions")
+		case !rl.lowercase && !rl.sensitive:
+			return nil, fmt.Errorf("sorted records require WithFastHas or WithCaseSensitive")
+		case rl.shardCount > 0:
+			return nil, fmt.Errorf("sorted records can't be sharded")
+		}
+		rl.sorted, rl.records = []string{}, nil
 	}
 
 	// Set default functions if not provided
-	if fnHas == nil {
+	if rl.unicode {
+		rl.setUnicodeFuncs()
+	}
+	if rl.fnHas == nil {
 		rl.fnHas = DefaultHasFunc
+		rl.defaultHas = !rl.lowercase && !rl.sensitive
+		if rl.lowercase {
+			rl.fnHas = LowercaseHasFunc
+		}
+		if rl.sensitive {
+			rl.fnHas = CaseSensitiveHasFunc
+		}
 	}
-	if fnHasPrefix == nil {
+	if rl.fnHasPrefix == nil {
 		rl.fnHasPrefix = DefaultHasPrefixFunc
+		if rl.sensitive {
+			rl.fnHasPrefix = CaseSensitiveHasPrefixFunc
+		}
 	}
-	if fnHasSuffix == nil {
+	if rl.fnHasSuffix == nil {
 		rl.fnHasSuffix = DefaultHasSuffixFunc
+		if rl.sensitive {
+			rl.fnHasSuffix = CaseSensitiveHasSuffixFunc
+		}
 	}
 
-	if fnSearch == nil {
+	if rl.fnSearch == nil {
 		rl.fnSearch = DefaultSearchFunc
+		if rl.sensitive {
+			rl.fnSearch = CaseSensitiveSearchFunc
+		}
+	}
+	if rl.fnDistance == nil {
+		rl.fnDistance = DefaultDistanceFunc
+	}
+	if rl.fnMatch == nil {
+		rl.fnMatch = DefaultMatchFunc
+		if rl.sensitive {
+			rl.fnMatch = CaseSensitiveMatchFunc
+		}
 	}
 
-	if fnDataLine == nil {
-		rl.fnDataLine = DefaultDataLineProcessFunc
+	if rl.commentPrefixes != nil || rl.commentInline != "" {
+		rl.fnDataLine = commentLineFunc(rl.commentPrefixes, rl.commentInline, rl.fnDataLine)
 	}
 
-	// Download and initialize the list
-	if err := rl.download(); err != nil {
+	rl.refreshAge = rl.jitterMaxAge()
+
+	if rl.userAgent == "" {
+		rl.userAgent = DefaultUserAgent
+	}
+
+	if err := rl.configureClient(); err != nil {
 		return nil, err
 	}
 
-	return rl, rl.init()
-}
+	if rl.ephemeral && (rl.memoryOnly || rl.storage != nil) {
+		return nil, fmt.Errorf("an ephemeral cache can't be combined with WithoutLocalFile or WithStorage")
+	}
+
+	if rl.fileLocal == "" && !rl.ephemeral {
+		rl.memoryOnly = true
+	}
+
+	if rl.memoryOnly && rl.backups > 0 {
+		return nil, fmt.Errorf("backups can't be combined with WithoutLocalFile")
+	}
+
+	if rl.memoryOnly {
+		rl.storage, rl.compress = NewMemoryStorage(), false
+	} else if rl.storage == nil {
+		rl.storage = FileStorage{}
+	}
+
+	if _, ok := rl.storage.(FileStorage); rl.resume && !ok {
+		return nil, fmt.Errorf("resumable downloads require the local file system")
+	}
+
+	if rl.fnDataLine == nil {
+		rl.fnDataLine = DefaultDataLineProcessFunc
+	}
+
+	if rl.fnStreamFilter == nil && rl.fnDataFiler != nil {
+		rl.fnStreamFilter = dataFilterStream(rl.fnDataFiler)
+	}
+
+	if rl.hostsFile && rl.split != nil {
+		return nil, fmt.Errorf("hosts files can't be parsed as CSV or JSON")
+	}
+	if rl.split == nil {
+		if rl.maxLine <= 0 {
+			rl.maxLine = DefaultMaxLineLength
+		}
+		rl.split = splitLines(rl.maxLine)
+		if rl.hostsFile {
+			rl.split = splitHosts(rl.split)
+		}
+	}
+
+	// Create the ephemeral cache last, so it can't leak if the configuration is invalid
+	if rl.ephemeral {
+		dir, err := os.MkdirTemp("", "remotelist-")
+		if err != nil {
+			return nil, fmt.Errorf("%w, could not create ephemeral cache: %w", ErrWriteLocal, err)
+		}
+		rl.ephemeralDir = dir
+		rl.fileLocal = filepath.Join(dir, "list")
+	}
 
-func NewSimple(fileLocal, fileRemote string, maxAge time.Duration) (*RemoteList, error) {
-	return New(fileLocal, fileRemote, maxAge, nil, nil, nil, nil, nil, nil)
+	return rl, nil
 }
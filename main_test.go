@@ -0,0 +1,428 @@
+package remotelist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRemote is a remote location serving a list whose content can be changed, it counts the requests
+type testRemote struct {
+	*httptest.Server
+	mu     sync.Mutex
+	body   string
+	status atomic.Int32 // Status code of the responses if not 0, the body is only served with 200 OK
+	hits   atomic.Int32
+}
+
+// newTestRemote starts a testRemote serving `body`, it is closed when the test ends
+func newTestRemote(t *testing.T, body string) *testRemote {
+	t.Helper()
+	r := &testRemote{body: body}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		r.hits.Add(1)
+		if status := int(r.status.Load()); status != 0 && status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		r.mu.Lock()
+		body := r.body
+		r.mu.Unlock()
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+// set changes the content served by the testRemote
+func (r *testRemote) set(body string) {
+	r.mu.Lock()
+	r.body = body
+	r.mu.Unlock()
+}
+
+// newTestList creates a RemoteList for `remote` with its local file in a temporary directory,
+// it is closed when the test ends
+func newTestList(t *testing.T, remote string, opts ...Option) *RemoteList {
+	t.Helper()
+	rl, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), remote, opts...)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	t.Cleanup(func() { rl.Close() })
+	return rl
+}
+
+// eventually fails the test if `cond` doesn't become true within a second
+func eventually(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met within a second")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestLen(t *testing.T) {
+	remote := newTestRemote(t, "a.com\nb.com\n")
+	rl := newTestList(t, remote.URL)
+
+	if n := rl.Len(); n != 2 {
+		t.Fatalf("Len() = %d, want 2", n)
+	}
+	rl.Add("c.com")
+	if n := rl.Len(); n != 3 {
+		t.Fatalf("Len() after Add = %d, want 3", n)
+	}
+	rl.Remove("a.com")
+	if n := rl.Len(); n != 2 {
+		t.Fatalf("Len() after Remove = %d, want 2", n)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 10 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			rl.Add(fmt.Sprintf("host%d.com", i))
+		}()
+		go func() {
+			defer wg.Done()
+			_ = rl.Len()
+			_ = rl.Search("host")
+		}()
+	}
+	wg.Wait()
+	if n := rl.Len(); n != 12 {
+		t.Fatalf("Len() after concurrent Add = %d, want 12", n)
+	}
+}
+
+func TestStartAutoRefresh(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	rl := newTestList(t, remote.URL, WithMaxAge(RefreshAlways))
+	rl.StartAutoRefresh(10 * time.Millisecond)
+
+	remote.set("b.com\n")
+	eventually(t, func() bool { return rl.Has("b.com") && !rl.Has("a.com") })
+
+	// A failed refresh keeps the records and is reported by LastError
+	remote.status.Store(http.StatusInternalServerError)
+	eventually(t, func() bool { return rl.LastError() != nil })
+	if !rl.Has("b.com") {
+		t.Fatal("failed refresh dropped the records")
+	}
+
+	rl.Stop()
+	remote.status.Store(0)
+	remote.set("c.com\n")
+	hits := remote.hits.Load()
+	time.Sleep(50 * time.Millisecond)
+	if remote.hits.Load() != hits || rl.Has("c.com") {
+		t.Fatal("refreshed after Stop")
+	}
+}
+
+func TestStartAutoRefreshInvalidInterval(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	rl := newTestList(t, remote.URL)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		rl.StartAutoRefresh(interval) // must not panic
+	}
+	rl.Stop()
+}
+
+// testDomains returns `n` distinct hostnames
+func testDomains(n int) []string {
+	domains := make([]string, n)
+	for i := range domains {
+		domains[i] = fmt.Sprintf("host%d.example%d.com", i, i%1000)
+	}
+	return domains
+}
+
+// newBenchList creates a static RemoteList with `n` hostnames as records
+func newBenchList(b *testing.B, n int, opts ...Option) *RemoteList {
+	b.Helper()
+	rl, err := NewFromStrings(testDomains(n), opts...)
+	if err != nil {
+		b.Fatalf("NewFromStrings: %v", err)
+	}
+	b.Cleanup(func() { rl.Close() })
+	return rl
+}
+
+// BenchmarkHasParallel measures concurrent lookups, which share the read lock instead of serializing
+func BenchmarkHasParallel(b *testing.B) {
+	rl := newBenchList(b, 100_000, WithFastHas())
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for i := 0; pb.Next(); i++ {
+			rl.Has(fmt.Sprintf("host%d.example%d.com", i, i%1000))
+		}
+	})
+}
+
+func BenchmarkHas(b *testing.B) {
+	for _, n := range []int{1_000, 100_000} {
+		b.Run(fmt.Sprintf("scan/%d", n), func(b *testing.B) {
+			rl := newBenchList(b, n)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rl.Has("HOST999.example999.com")
+			}
+		})
+		b.Run(fmt.Sprintf("map/%d", n), func(b *testing.B) {
+			rl := newBenchList(b, n, WithFastHas())
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rl.Has("HOST999.example999.com")
+			}
+		})
+	}
+}
+
+func TestHasBatch(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"scan", nil},
+		{"map", []Option{WithFastHas()}},
+		{"custom HasFunc", []Option{WithHasFunc(DefaultHasFunc)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromStrings([]string{"a.com", "B.com"}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The keys are the values as given, even if they only differ in case
+			got := rl.HasBatch([]string{"A.COM", "a.com", "b.COM", "c.com"})
+			want := map[string]bool{"A.COM": true, "a.com": true, "b.COM": true, "c.com": false}
+			if len(got) != len(want) {
+				t.Fatalf("HasBatch() = %v, want %v", got, want)
+			}
+			for v, ok := range want {
+				if got[v] != ok {
+					t.Errorf("HasBatch()[%q] = %v, want %v", v, got[v], ok)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHasBatch(b *testing.B) {
+	rl := newBenchList(b, 100_000)
+	values := testDomains(200)
+	for i := range values {
+		values[i] = strings.ToUpper(values[i]) + "x"
+	}
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, v := range values {
+				rl.Has(v)
+			}
+		}
+	})
+	b.Run("batch", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			rl.HasBatch(values)
+		}
+	})
+}
+
+// BenchmarkLoad measures parsing a large local file. The file is streamed, so the allocations are
+// dominated by the records and not by the size of the file.
+func BenchmarkLoad(b *testing.B) {
+	local := filepath.Join(b.TempDir(), "list.txt")
+	content := strings.Join(testDomains(200_000), "\n") + "\n"
+	if err := os.WriteFile(local, []byte(content), 0644); err != nil {
+		b.Fatal(err)
+	}
+	rl, err := NewWithOptions(local, "http://127.0.0.1:0/list.txt", WithMaxAge(time.Hour))
+	if err != nil {
+		b.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	b.SetBytes(int64(len(content)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := rl.init(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestRefreshDropsRemovedRecords(t *testing.T) {
+	remote := newTestRemote(t, "a.com\nb.com\nc.com\n")
+	rl := newTestList(t, remote.URL)
+
+	remote.set("a.com\nc.com\n")
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rl.Has("b.com") || rl.Len() != 2 {
+		t.Errorf("List() = %q, want [a.com c.com]", rl.List())
+	}
+
+	// A failed download keeps the previous records as they were
+	remote.status.Store(http.StatusInternalServerError)
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if rl.Has("b.com") || rl.Len() != 2 {
+		t.Errorf("List() after failed refresh = %q, want [a.com c.com]", rl.List())
+	}
+}
+
+func TestConcurrentRefresh(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		// Slow enough for all refreshes to start while the download is in progress
+		time.Sleep(200 * time.Millisecond)
+		fmt.Fprint(w, "a.com\n")
+	}))
+	defer srv.Close()
+	rl := newTestList(t, srv.URL)
+	hits.Store(0)
+
+	start := make(chan struct{})
+	errs := make(chan error, 20)
+	var wg sync.WaitGroup
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			errs <- rl.Refresh(true)
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Refresh: %v", err)
+		}
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("20 concurrent refreshes made %d requests, want 1", n)
+	}
+	if !rl.Has("a.com") {
+		t.Errorf("List() = %q, want [a.com]", rl.List())
+	}
+}
+
+func TestHasAnyHasAll(t *testing.T) {
+	tests := []struct {
+		values []string
+		any    bool
+		all    bool
+	}{
+		{nil, false, true},
+		{[]string{"a.com"}, true, true},
+		{[]string{"A.COM", "b.com"}, true, true},
+		{[]string{"a.com", "x.com"}, true, false},
+		{[]string{"x.com", "b.com"}, true, false},
+		{[]string{"x.com", "y.com"}, false, false},
+	}
+	for _, opts := range [][]Option{nil, {WithFastHas()}, {WithHasFunc(DefaultHasFunc)}} {
+		rl, err := NewFromStrings([]string{"a.com", "b.com"}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			if got := rl.HasAny(tt.values...); got != tt.any {
+				t.Errorf("HasAny(%q) = %v, want %v", tt.values, got, tt.any)
+			}
+			if got := rl.HasAll(tt.values...); got != tt.all {
+				t.Errorf("HasAll(%q) = %v, want %v", tt.values, got, tt.all)
+			}
+		}
+	}
+}
+
+// BenchmarkHasDuringRefresh measures the latency of Has while the list is reloaded over and over.
+// The records are swapped in under a brief lock, so the p99 latency should match the idle one.
+func BenchmarkHasDuringRefresh(b *testing.B) {
+	domains := testDomains(200_000)
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, strings.Join(domains, "\n"))
+	}))
+	defer remote.Close()
+
+	for _, refresh := range []bool{false, true} {
+		b.Run(fmt.Sprintf("refreshing=%v", refresh), func(b *testing.B) {
+			rl, err := NewWithOptions(filepath.Join(b.TempDir(), "list.txt"), remote.URL, WithFastHas())
+			if err != nil {
+				b.Fatalf("NewWithOptions: %v", err)
+			}
+			defer rl.Close()
+
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			if refresh {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for {
+						select {
+						case <-stop:
+							return
+						default:
+							_ = rl.Refresh(true)
+						}
+					}
+				}()
+			}
+
+			latencies := make([]time.Duration, b.N)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				start := time.Now()
+				rl.Has(domains[i%len(domains)])
+				latencies[i] = time.Since(start)
+			}
+			b.StopTimer()
+			close(stop)
+			wg.Wait()
+
+			slices.Sort(latencies)
+			b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns")
+		})
+	}
+}
+
+func TestWithAllowEmptyStart(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	remote.status.Store(http.StatusInternalServerError)
+	rl := newTestList(t, remote.URL, WithAllowEmptyStart())
+
+	var errStatus *StatusError
+	if rl.Len() != 0 || !rl.IsStale() || !errors.As(rl.LastError(), &errStatus) || errStatus.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("got %d records, stale %v and error %v, want an empty stale list with the 500", rl.Len(), rl.IsStale(), rl.LastError())
+	}
+
+	remote.status.Store(0)
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if !rl.Has("a.com") || rl.IsStale() || rl.LastError() != nil {
+		t.Errorf("got records %q, stale %v and error %v after a successful refresh", rl.List(), rl.IsStale(), rl.LastError())
+	}
+}
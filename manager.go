@@ -0,0 +1,169 @@
+package remotelist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// DefaultManagerWorkers is the number of lists a Manager refreshes concurrently unless configured otherwise.
+const DefaultManagerWorkers = 4
+
+// Manager coordinates multiple named RemoteLists, e.g. to check a value against all of them or to refresh them together.
+type Manager struct {
+	mu      *sync.RWMutex
+	lists   map[string]*RemoteList
+	workers int          // Maximum number of lists refreshed concurrently
+	limiter *RateLimiter // Limits the aggregate bandwidth of the downloads of all lists, nil if unlimited
+}
+
+// A `ManagerOption` configures optional behavior of a Manager.
+type ManagerOption func(m *Manager)
+
+// WithWorkers sets the maximum number of lists the Manager refreshes concurrently.
+func WithWorkers(n int) ManagerOption {
+	return func(m *Manager) {
+		if n > 0 {
+			m.workers = n
+		}
+	}
+}
+
+// WithSharedRateLimiter limits the aggregate bandwidth of the downloads of all lists in the Manager with `l`.
+// Limits set on the lists themselves with WithDownloadRateLimit still apply.
+func WithSharedRateLimiter(l *RateLimiter) ManagerOption {
+	return func(m *Manager) {
+		m.limiter = l
+	}
+}
+
+// NewManager creates a new Manager without any lists
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		mu:      &sync.RWMutex{},
+		lists:   map[string]*RemoteList{},
+		workers: DefaultManagerWorkers,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Add adds `rl` to the Manager under `name`, replacing the list previously added under that name.
+// If the Manager has a shared rate limiter, the downloads of `rl` are limited by it from now on.
+func (m *Manager) Add(name string, rl *RemoteList) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if old, ok := m.lists[name]; ok && old != rl {
+		m.release(old)
+	}
+	m.lists[name] = rl
+	if m.limiter != nil {
+		rl.mu.Lock()
+		rl.sharedLimiter = m.limiter
+		rl.mu.Unlock()
+	}
+}
+
+// Remove removes the list with the given `name` from the Manager and reports whether it existed.
+// The downloads of the list are no longer limited by the shared rate limiter of the Manager.
+func (m *Manager) Remove(name string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rl, ok := m.lists[name]
+	if ok {
+		m.release(rl)
+	}
+	delete(m.lists, name)
+	return ok
+}
+
+// release detaches `rl` from the shared rate limiter of the Manager
+func (m *Manager) release(rl *RemoteList) {
+	rl.mu.Lock()
+	if rl.sharedLimiter == m.limiter {
+		rl.sharedLimiter = nil
+	}
+	rl.mu.Unlock()
+}
+
+// Get returns the list with the given `name`
+func (m *Manager) Get(name string) (rl *RemoteList, ok bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	rl, ok = m.lists[name]
+	return rl, ok
+}
+
+// Names returns the sorted names of all lists
+func (m *Manager) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.lists))
+	for name := range m.lists {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HasAny checks the lists in the order of their names and returns the name of the first list that has `value`
+func (m *Manager) HasAny(value string) (listName string, found bool) {
+	for _, name := range m.Names() {
+		if rl, ok := m.Get(name); ok && rl.Has(value) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// RefreshAll refreshes all lists whose local file is older than their maxAge (see RemoteList.RefreshContext),
+// using up to the configured number of workers. Failing lists don't stop the others from refreshing,
+// their errors are joined into the returned error.
+func (m *Manager) RefreshAll(ctx context.Context) error {
+	names := m.Names()
+	jobs := make(chan string)
+	errs := make([]error, len(names))
+	index := make(map[string]int, len(names))
+	for i, name := range names {
+		index[name] = i
+	}
+
+	wg := &sync.WaitGroup{}
+	for i := 0; i < m.workers && i < len(names); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				rl, ok := m.Get(name)
+				if !ok {
+					continue
+				}
+				if err := rl.RefreshContext(ctx, false); err != nil {
+					errs[index[name]] = fmt.Errorf("%s: %w", name, err)
+				}
+			}
+		}()
+	}
+	for _, name := range names {
+		jobs <- name
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// Stats returns the stats of all lists by name
+func (m *Manager) Stats() map[string]Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	stats := make(map[string]Stats, len(m.lists))
+	for name, rl := range m.lists {
+		stats[name] = rl.Stats()
+	}
+	return stats
+}
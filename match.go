@@ -0,0 +1,73 @@
+package remotelist
+
+import (
+	"sort"
+	"strings"
+)
+
+// MatchScore ranks how well a record matches a search term, higher is better
+type MatchScore int
+
+const (
+	MatchSubstring MatchScore = iota + 1 // The term occurs somewhere in the record
+	MatchPrefix                          // The record starts with the term
+	MatchExact                           // The record is the term
+)
+
+// A `Match` is a record found by SearchDetailed
+type Match struct {
+	Record string     // The matching record
+	Index  int        // Byte offset of the term in the record
+	Score  MatchScore // How well the record matches
+	Source Source     // Origin of the record
+}
+
+// A `MatchFunc` is used by SearchDetailed to check if `record` matches `term`. If so, it returns `ok = true`
+// and the Match describing where and how well it matches.
+type MatchFunc func(record, term string) (m Match, ok bool)
+
+var (
+	// The default `Match` function matches case-insensitive substrings. The Index refers to the lowercased record.
+	DefaultMatchFunc = func(record, term string) (m Match, ok bool) {
+		return CaseSensitiveMatchFunc(strings.ToLower(record), strings.ToLower(term))
+	}
+
+	// The `CaseSensitiveMatch` function matches case-sensitive substrings. It is the default with WithCaseSensitive.
+	CaseSensitiveMatchFunc = func(record, term string) (m Match, ok bool) {
+		i := strings.Index(record, term)
+		switch {
+		case i < 0:
+			return Match{}, false
+		case len(record) == len(term):
+			return Match{Record: record, Index: i, Score: MatchExact}, true
+		case i == 0:
+			return Match{Record: record, Index: i, Score: MatchPrefix}, true
+		}
+		return Match{Record: record, Index: i, Score: MatchSubstring}, true
+	}
+)
+
+// SearchDetailed searches for `term` like Search, but describes each match with the position of the term
+// and a score (exact match, prefix or substring). The matches are sorted by score (best first), then by record.
+// Matching is done by the MatchFunc (see WithMatchFunc), the configured SearchFunc is not used.
+func (rl *RemoteList) SearchDetailed(term string) []Match {
+	term = rl.query(term)
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	sourceOf := rl.sources()
+	res := []Match{}
+	rl.eachRecord(func(rec string) bool {
+		if m, ok := rl.fnMatch(rec, term); ok {
+			m.Record, m.Source = rec, sourceOf(rec)
+			res = append(res, m)
+		}
+		return true
+	})
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Score != res[j].Score {
+			return res[i].Score > res[j].Score
+		}
+		return res[i].Record < res[j].Record
+	})
+	return res
+}
@@ -0,0 +1,53 @@
+package remotelist
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+// metadata holds information about the last download of a list.
+// It is stored in a sidecar file next to the local file.
+type metadata struct {
+	ETag          string `json:"etag,omitempty"`           // ETag header of the last download
+	LastModified  string `json:"last_modified,omitempty"`  // Last-Modified header of the last download
+	ContentLength int64  `json:"content_length,omitempty"` // Content-Length header of the last download, 0 if unknown
+}
+
+// metadataFile returns the path of the sidecar file that stores the metadata of `fileLocal`
+func metadataFile(fileLocal string) string {
+	return fileLocal + ".meta"
+}
+
+// readMetadata reads the metadata of `fileLocal` from `storage`, returning empty metadata if there is none
+func readMetadata(storage Storage, fileLocal string) metadata {
+	meta := metadata{}
+	f, err := storage.Open(metadataFile(fileLocal))
+	if err != nil {
+		return meta
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return meta
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return metadata{}
+	}
+	return meta
+}
+
+// writeMetadata writes the metadata of `fileLocal` to `storage`. Empty metadata removes the sidecar file.
+func writeMetadata(storage Storage, fileLocal string, meta metadata, permissions os.FileMode) error {
+	if meta == (metadata{}) {
+		return storage.Remove(metadataFile(fileLocal))
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return storage.Write(metadataFile(fileLocal), permissions, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
@@ -0,0 +1,64 @@
+package remotelist
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConditionalDownload(t *testing.T) {
+	var conditional, full int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` && r.Header.Get("If-Modified-Since") != "" {
+			conditional++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", time.Now().UTC().Format(http.TimeFormat))
+		fmt.Fprint(w, "a.com\n")
+	}))
+	defer srv.Close()
+
+	local := filepath.Join(t.TempDir(), "list.txt")
+	rl, err := NewWithOptions(local, srv.URL, WithMaxAge(time.Hour))
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+
+	// Age the local file, so the 304 has to reset its age
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(local, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if full != 1 || conditional != 1 {
+		t.Fatalf("got %d full and %d conditional downloads, want 1 and 1", full, conditional)
+	}
+	fileInfo, err := os.Stat(local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(fileInfo.ModTime()) > time.Minute {
+		t.Errorf("304 did not touch the local file, modified %s", fileInfo.ModTime())
+	}
+	if !rl.Has("a.com") || rl.IsStale() {
+		t.Errorf("records after 304: %v, stale %v", rl.List(), rl.IsStale())
+	}
+
+	// The file is fresh again, so there is no request at all
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if full+conditional != 2 {
+		t.Errorf("fresh list was downloaded again")
+	}
+}
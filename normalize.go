@@ -0,0 +1,38 @@
+package remotelist
+
+import (
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+var (
+	// The `Lowercase` function normalizes a value to lowercase.
+	Lowercase NormalizeFunc = strings.ToLower
+
+	// The `TrimDot` function strips trailing dots, e.g. from fully qualified hostnames ("example.com." becomes "example.com").
+	TrimDot NormalizeFunc = func(value string) string {
+		return strings.TrimRight(value, ".")
+	}
+
+	// The `StripScheme` function strips a URL scheme ("https://example.com" becomes "example.com").
+	StripScheme NormalizeFunc = func(value string) string {
+		if i := strings.Index(value, "://"); i > 0 && !strings.ContainsAny(value[:i], "/?#") {
+			return value[i+3:]
+		}
+		return value
+	}
+
+	// The `Punycode` function converts internationalized hostnames to their ASCII form, so both spellings
+	// match ("bücher.example" becomes "xn--bcher-kva.example"). Labels are also lowercased and mapped like
+	// in DNS lookups. Labels that are not valid IDNs are kept verbatim.
+	Punycode NormalizeFunc = func(value string) string {
+		labels := strings.Split(value, ".")
+		for i, label := range labels {
+			if ascii, err := idna.Lookup.ToASCII(label); err == nil {
+				labels[i] = ascii
+			}
+		}
+		return strings.Join(labels, ".")
+	}
+)
@@ -0,0 +1,50 @@
+package remotelist
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeFuncs(t *testing.T) {
+	tests := []struct {
+		name  string
+		fn    NormalizeFunc
+		value string
+		want  string
+	}{
+		{"Lowercase", Lowercase, "Example.COM", "example.com"},
+		{"TrimDot", TrimDot, "example.com.", "example.com"},
+		{"TrimDot", TrimDot, "example.com", "example.com"},
+		{"StripScheme", StripScheme, "https://example.com/path", "example.com/path"},
+		{"StripScheme", StripScheme, "example.com/?next=https://other.com", "example.com/?next=https://other.com"},
+		{"Punycode", Punycode, "Bücher.example", "xn--bcher-kva.example"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.value); got != tt.want {
+			t.Errorf("%s(%q) = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWithNormalizeFunc(t *testing.T) {
+	rl, err := NewFromReader(strings.NewReader("HTTPS://Downloaded.COM.\n"),
+		WithNormalizeFunc(StripScheme, Lowercase, TrimDot), WithCaseSensitive())
+	if err != nil {
+		t.Fatalf("NewFromReader: %v", err)
+	}
+	defer rl.Close()
+	rl.Add("Example.COM.")
+
+	// Records and query terms are normalized the same way, even if the matching is case-sensitive
+	for _, term := range []string{"example.com", "EXAMPLE.com.", "http://example.com", "downloaded.com"} {
+		if !rl.Has(term) {
+			t.Errorf("Has(%q) = false, want true", term)
+		}
+	}
+	if !rl.HasPrefix("Example.") || !rl.HasSuffix(".COM.") {
+		t.Error("HasPrefix or HasSuffix did not normalize the term")
+	}
+	if got := rl.Search("EXAMPLE"); len(got) != 1 || got[0] != "example.com" {
+		t.Errorf("Search(EXAMPLE) = %q, want [example.com]", got)
+	}
+}
@@ -0,0 +1,771 @@
+package remotelist
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// An `Option` configures optional behavior of a RemoteList.
+// Options are applied in the given order before the list is downloaded for the first time.
+type Option func(rl *RemoteList) error
+
+// WithMaxAge sets the maximum age of the local file before the list is downloaded again.
+// Use RefreshAlways to download the list on every refresh and RefreshNever to only download it
+// if there is no local file. Negative durations are an error.
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(rl *RemoteList) error {
+		if maxAge < 0 {
+			return fmt.Errorf("invalid max age: %s", maxAge)
+		}
+		rl.maxAge = maxAge
+		return nil
+	}
+}
+
+// WithWatch checks the local file every `interval` and parses it again if another process changed it,
+// e.g. a sidecar or another process using the same local file, without downloading it. A change is picked up
+// once the file stayed the same for one interval, it counts as a successful refresh. If the changed file
+// can't be read, the current records are kept. Stop ends the watching. Processes that share the local file
+// should combine it with WithFileLock, so only one of them downloads the list and the others pick it up.
+func WithWatch(interval time.Duration) Option {
+	return func(rl *RemoteList) error {
+		if interval <= 0 {
+			return fmt.Errorf("invalid watch interval: %s", interval)
+		}
+		rl.watchInterval = interval
+		return nil
+	}
+}
+
+// WithRefreshJitter randomizes the maxAge by up to ±`fraction` (e.g. 0.1 for ±10%), so lists that were
+// created at the same time don't all refresh at the same time. A new random maxAge is picked after every
+// download, so the lists drift further apart over time. Use NextRefreshAt to see when a list is due.
+func WithRefreshJitter(fraction float64) Option {
+	return func(rl *RemoteList) error {
+		if fraction < 0 || fraction >= 1 {
+			return fmt.Errorf("invalid refresh jitter: %v", fraction)
+		}
+		rl.jitter = fraction
+		return nil
+	}
+}
+
+// WithHasFunc sets the function used by Has. If `fn` is `nil`, `DefaultHasFunc` is used.
+func WithHasFunc(fn HasFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnHas = fn
+		return nil
+	}
+}
+
+// WithHasPrefixFunc sets the function used by HasPrefix. If `fn` is `nil`, `DefaultHasPrefixFunc` is used.
+func WithHasPrefixFunc(fn HasFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnHasPrefix = fn
+		return nil
+	}
+}
+
+// WithHasSuffixFunc sets the function used by HasSuffix. If `fn` is `nil`, `DefaultHasSuffixFunc` is used.
+func WithHasSuffixFunc(fn HasFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnHasSuffix = fn
+		return nil
+	}
+}
+
+// WithSearchFunc sets the function used by Search. If `fn` is `nil`, `DefaultSearchFunc` is used.
+func WithSearchFunc(fn SearchFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnSearch = fn
+		return nil
+	}
+}
+
+// WithMatchFunc sets the function used by SearchDetailed. If `fn` is `nil`, `DefaultMatchFunc` is used.
+func WithMatchFunc(fn MatchFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnMatch = fn
+		return nil
+	}
+}
+
+// WithDistanceFunc sets the function used by SearchFuzzy. If `fn` is `nil`, `DefaultDistanceFunc` is used.
+func WithDistanceFunc(fn DistanceFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnDistance = fn
+		return nil
+	}
+}
+
+// WithDataFilter sets the function that is run over the downloaded content before it is saved to disk.
+func WithDataFilter(fn DataFilterFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnDataFiler = fn
+		return nil
+	}
+}
+
+// WithStreamFilter sets the function that filters the downloaded content while it is written to the local file.
+// It takes precedence over a DataFilterFunc set with WithDataFilter.
+func WithStreamFilter(fn StreamFilterFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnStreamFilter = fn
+		return nil
+	}
+}
+
+// WithDataLine sets the function that is run over each line of the local file.
+// If `fn` is `nil`, `DefaultDataLineProcessFunc` is used.
+func WithDataLine(fn DataLineFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnDataLine = fn
+		return nil
+	}
+}
+
+// WithCommentPrefixes excludes lines starting with any of the `prefixes` (e.g. ";" for zone files)
+// instead of `#` and `//`. Lines are trimmed before checking for the prefixes and empty lines are excluded.
+// A DataLineFunc set with WithDataLine is run on the remaining lines.
+func WithCommentPrefixes(prefixes ...string) Option {
+	return func(rl *RemoteList) error {
+		rl.commentPrefixes = append([]string{}, prefixes...)
+		return nil
+	}
+}
+
+// WithInlineComments strips everything from `marker` to the end of the line (e.g. "1.2.3.4 # added 2024-01-02")
+// and trims the remainder, lines that are empty afterwards are excluded. Unless WithCommentPrefixes is given,
+// lines starting with `#` or `//` are excluded as well. A DataLineFunc set with WithDataLine is run on the remaining lines.
+func WithInlineComments(marker string) Option {
+	return func(rl *RemoteList) error {
+		if marker == "" {
+			return fmt.Errorf("empty inline comment marker")
+		}
+		rl.commentInline = marker
+		return nil
+	}
+}
+
+// WithStrict makes the RemoteList fail if the list can't be downloaded, even if an outdated local file exists.
+// By default the local file is used and the RemoteList is marked as stale.
+func WithStrict() Option {
+	return func(rl *RemoteList) error {
+		rl.strict = true
+		return nil
+	}
+}
+
+// WithAllowEmptyStart lets the constructor succeed without records if the list can't be downloaded and there is
+// no local file, instead of returning the error. IsStale reports `true` and LastError returns the error
+// until a later Refresh (e.g. by StartAutoRefresh) loads the list.
+func WithAllowEmptyStart() Option {
+	return func(rl *RemoteList) error {
+		rl.allowEmpty = true
+		return nil
+	}
+}
+
+// WithLazyInit defers the first download and parsing of the list until the records are used for the first time,
+// e.g. by Has or List, so the constructor returns immediately. Callers that use the list at the same time wait
+// for the same load. If it fails, the list stays empty and LastError returns the error; use EnsureLoaded
+// to load the list explicitly and to get the error.
+func WithLazyInit() Option {
+	return func(rl *RemoteList) error {
+		rl.lazy = &lazyLoader{}
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to download the list. By default `DefaultHTTPClient` is used.
+func WithHTTPClient(client *http.Client) Option {
+	return func(rl *RemoteList) error {
+		if client != nil {
+			rl.client = client
+		}
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration used for downloads, e.g. to pin a certificate.
+// It is applied to a copy of the HTTP client (see WithHTTPClient), so it composes with its timeout and
+// with WithCACert and WithProxy. The client's transport must be an *http.Transport or unset.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(rl *RemoteList) error {
+		rl.tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithCACert trusts the PEM-encoded certificates in `pemCerts` in addition to the system's certificate authorities,
+// e.g. for lists served with a private CA. Like WithTLSConfig it is applied to a copy of the HTTP client.
+func WithCACert(pemCerts []byte) Option {
+	return func(rl *RemoteList) error {
+		pool := rl.rootCAs
+		if pool == nil {
+			var err error
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(pemCerts) {
+			return fmt.Errorf("no valid PEM certificates found")
+		}
+		rl.rootCAs = pool
+		return nil
+	}
+}
+
+// WithProxy routes the downloads of this RemoteList through the proxy at `proxyURL`
+// (http, https or socks5 scheme) instead of the proxy configured by the environment.
+// Like WithTLSConfig it is applied to a copy of the HTTP client.
+func WithProxy(proxyURL string) Option {
+	return func(rl *RemoteList) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("invalid proxy URL %q: unsupported scheme", proxyURL)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q: missing host", proxyURL)
+		}
+		rl.proxy = u
+		return nil
+	}
+}
+
+// WithFetcher retrieves the list (and its mirrors) with `f` instead of the HTTP client, e.g. from an S3 bucket.
+// Headers, the request modifier and skipping unmodified lists only apply to HTTP downloads,
+// everything else (maxAge, filters, checksums, size limits and atomic writes) works the same for every Fetcher.
+func WithFetcher(f Fetcher) Option {
+	return func(rl *RemoteList) error {
+		rl.fetcher = f
+		return nil
+	}
+}
+
+// WithStorage stores the local file, the local overrides and the sidecar files in `storage` instead of the
+// local file system, e.g. a MemoryStorage for read-only containers.
+func WithStorage(storage Storage) Option {
+	return func(rl *RemoteList) error {
+		rl.storage = storage
+		return nil
+	}
+}
+
+// WithEphemeralCache keeps the local file in a new temporary directory (see os.TempDir) instead of `fileLocal`,
+// which is removed together with the directory by Close, e.g. for tests and one-shot tools. Stats.LocalPath
+// returns the path. It can't be combined with WithoutLocalFile or WithStorage.
+func WithEphemeralCache() Option {
+	return func(rl *RemoteList) error {
+		rl.ephemeral = true
+		return nil
+	}
+}
+
+// WithoutLocalFile keeps the list in memory only, nothing is written to disk. The download is parsed while it
+// is received and isn't stored, only its age and the validators for conditional requests are kept, so maxAge
+// is measured from the last download and a failed refresh keeps the current records. Passing an empty
+// `fileLocal` to the constructors has the same effect. It replaces a Storage set with WithStorage,
+// WithCompressedCache has no effect and it can't be combined with WithBackups.
+func WithoutLocalFile() Option {
+	return func(rl *RemoteList) error {
+		rl.memoryOnly = true
+		return nil
+	}
+}
+
+// WithDirMode sets the mode of the directories that are created if the directory of the local file doesn't exist.
+// By default `DefaultDirMode` is used. The mode is subject to the umask.
+func WithDirMode(mode os.FileMode) Option {
+	return func(rl *RemoteList) error {
+		if mode == 0 || mode&^os.ModePerm != 0 {
+			return fmt.Errorf("invalid directory mode: %s", mode)
+		}
+		rl.dirMode = mode
+		return nil
+	}
+}
+
+// WithFileMode sets the permissions of the local file and its sidecar files (metadata, backups, records
+// written by Save), which are applied on every write. By default new files get 0644 and existing files keep theirs.
+// The permissions are set explicitly and not reduced by the umask, except for the partial files of WithResume.
+func WithFileMode(mode os.FileMode) Option {
+	return func(rl *RemoteList) error {
+		if mode == 0 || mode&^os.ModePerm != 0 {
+			return fmt.Errorf("invalid file mode: %s", mode)
+		}
+		rl.fileMode = mode
+		return nil
+	}
+}
+
+// WithFileLock makes processes that share the local file take turns downloading the list: a process that
+// wants to download it waits for the download of another process and uses its result instead.
+// It waits at most `timeout` and fails with ErrLockTimeout then. The lock is advisory and only used
+// with the local file system, it is taken on a `.lock` file next to the local file. Use WithWatch to load
+// the records when another process downloaded the list.
+func WithFileLock(timeout time.Duration) Option {
+	return func(rl *RemoteList) error {
+		if timeout <= 0 {
+			return fmt.Errorf("invalid lock timeout: %s", timeout)
+		}
+		rl.lockTimeout = timeout
+		return nil
+	}
+}
+
+// WithoutCreateDirs disables creating the directory of the local file if it doesn't exist,
+// the download then fails with ErrWriteLocal.
+func WithoutCreateDirs() Option {
+	return func(rl *RemoteList) error {
+		rl.dirMode = 0
+		return nil
+	}
+}
+
+// WithHeaders adds the given headers to every download request, e.g. to authenticate with the list source.
+func WithHeaders(headers http.Header) Option {
+	return func(rl *RemoteList) error {
+		rl.headers = headers.Clone()
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every download request, the default is DefaultUserAgent.
+// A User-Agent set with WithHeaders or by the request modifier takes precedence.
+func WithUserAgent(ua string) Option {
+	return func(rl *RemoteList) error {
+		rl.userAgent = ua
+		return nil
+	}
+}
+
+// WithHeadCheck sends a HEAD request before downloading the list again and skips the download if the
+// Last-Modified and Content-Length headers match those of the previous download, e.g. for very large lists
+// on servers without proper ETag support. Servers that don't send Last-Modified, or that reject HEAD requests,
+// are downloaded from as usual.
+func WithHeadCheck() Option {
+	return func(rl *RemoteList) error {
+		rl.headCheck = true
+		return nil
+	}
+}
+
+// WithResume keeps the raw response of an interrupted HTTP download in a file next to the local file
+// (`<fileLocal>.part`) and resumes it with a range request on the next attempt, if the server supports that.
+// The size (and the checksum, if configured) of the resumed download is verified before it replaces the
+// local file. If the server sends the complete list instead, the download starts over.
+// It requires the local file system, so it can't be combined with WithStorage or WithoutLocalFile.
+func WithResume() Option {
+	return func(rl *RemoteList) error {
+		rl.resume = true
+		return nil
+	}
+}
+
+// WithRequestModifier sets a function that is run on every download request before it is sent.
+// It runs after the headers set with WithHeaders have been added.
+func WithRequestModifier(fn RequestModifierFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnRequest = fn
+		return nil
+	}
+}
+
+// WithDownloadTimeout limits the duration of a download, including reading the response, to `timeout`.
+// If the download takes longer it fails with `ErrDownloadTimeout`.
+func WithDownloadTimeout(timeout time.Duration) Option {
+	return func(rl *RemoteList) error {
+		rl.timeout = timeout
+		return nil
+	}
+}
+
+// WithDecompressors adds decompressors that are tried on downloaded content before the gzip decompressor.
+func WithDecompressors(decompressors ...Decompressor) Option {
+	return func(rl *RemoteList) error {
+		rl.decompress = append(append([]Decompressor{}, decompressors...), rl.decompress...)
+		return nil
+	}
+}
+
+// WithCompressedCache stores the local file gzip-compressed. Uncompressed local files are still read
+// and are replaced by a compressed version on the next refresh (and vice versa if the option is not used).
+func WithCompressedCache() Option {
+	return func(rl *RemoteList) error {
+		rl.compress = true
+		return nil
+	}
+}
+
+// WithMirrors adds remote locations from which the list is downloaded if downloading it from fileRemote fails.
+// They are tried in the given order.
+func WithMirrors(mirrors ...string) Option {
+	return func(rl *RemoteList) error {
+		rl.mirrors = append(rl.mirrors, mirrors...)
+		return nil
+	}
+}
+
+// WithDownloadRateLimit limits the bandwidth of the downloads of this RemoteList to `bytesPerSec` bytes per second.
+func WithDownloadRateLimit(bytesPerSec int64) Option {
+	return func(rl *RemoteList) error {
+		if bytesPerSec <= 0 {
+			return fmt.Errorf("invalid download rate limit: %d bytes per second", bytesPerSec)
+		}
+		rl.limiter = NewRateLimiter(bytesPerSec)
+		return nil
+	}
+}
+
+// WithBackups keeps up to `n` previous versions of the local file (`<fileLocal>.1` being the newest),
+// so Rollback can restore them. Once a new version has been downloaded completely and before it replaces the
+// local file, the older backups are renamed to the next generation and the current version is copied to the
+// newest one, so the current version is never lost. It can't be combined with WithoutLocalFile.
+func WithBackups(n int) Option {
+	return func(rl *RemoteList) error {
+		if n < 0 {
+			return fmt.Errorf("invalid number of backups: %d", n)
+		}
+		rl.backups = n
+		return nil
+	}
+}
+
+// WithValidator sets a function that validates a downloaded list before it replaces the local file, e.g. to
+// reject error pages served with status 200 (see MinRecords). It receives the records parsed from the download
+// (without local overrides and added records). If it returns an error, the local file and the current records
+// are kept and Refresh returns the error wrapped in ErrValidation. Use it multiple times to add more validators.
+func WithValidator(fn ValidatorFunc) Option {
+	return func(rl *RemoteList) error {
+		if prev := rl.fnValidate; prev != nil {
+			rl.fnValidate = func(recordCount int, records map[string]struct{}) error {
+				if err := prev(recordCount, records); err != nil {
+					return err
+				}
+				return fn(recordCount, records)
+			}
+			return nil
+		}
+		rl.fnValidate = fn
+		return nil
+	}
+}
+
+// WithMinRecords rejects downloaded lists with less than `n` records, which usually are error pages
+// rather than real lists. It is a shorthand for WithValidator(MinRecords(n)).
+func WithMinRecords(n int) Option {
+	return WithValidator(MinRecords(n))
+}
+
+// WithMaxShrink rejects downloaded lists that have more than `percent` percent fewer records than the list that
+// is currently loaded, e.g. 80 rejects a list that shrank to less than a fifth. Only the downloaded records are
+// compared, local overrides and records added with Add don't count. Sudden shrinkage is almost always an outage
+// page rather than a real change of the list. Rejected lists are handled like in WithValidator.
+func WithMaxShrink(percent float64) Option {
+	return func(rl *RemoteList) error {
+		if percent <= 0 || percent > 100 {
+			return fmt.Errorf("invalid shrink percentage: %g", percent)
+		}
+		return WithValidator(rl.maxShrink(percent))(rl)
+	}
+}
+
+// WithSHA256 verifies downloads against the given hex-encoded SHA-256 checksum.
+// The checksum is computed over the content as published, i.e. before decompression and the DataFilterFunc.
+// If it does not match, the download fails with `ErrChecksumMismatch` and the local file is left untouched.
+func WithSHA256(checksum string) Option {
+	return func(rl *RemoteList) error {
+		sum, err := parseChecksum(checksum)
+		if err != nil {
+			return err
+		}
+		rl.checksum = sum
+		return nil
+	}
+}
+
+// WithSHA256URL is like WithSHA256 but downloads the expected checksum from `checksumURL`
+// (e.g. a `.sha256` file published alongside the list) every time the list is downloaded.
+// It is only requested once the list has been received, not if the list did not change.
+func WithSHA256URL(checksumURL string) Option {
+	return func(rl *RemoteList) error {
+		rl.checksumURL = checksumURL
+		return nil
+	}
+}
+
+// WithRetry retries a failed download up to `retries` times before falling back to the next mirror.
+// The first retry waits for `backoff`, which doubles with every further retry. If the remote responds with
+// 429 Too Many Requests or 503 Service Unavailable and a Retry-After header, the advised delay (capped at
+// MaxRetryAfter) is waited for instead. Downloads that can't succeed, e.g. because of a checksum mismatch
+// or a 404 response, are not retried. If all attempts fail, a *StatusError carries the advised delay.
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(rl *RemoteList) error {
+		if retries < 0 || backoff < 0 {
+			return fmt.Errorf("invalid retry configuration: %d retries with a backoff of %s", retries, backoff)
+		}
+		rl.retries = retries
+		rl.retryBackoff = backoff
+		return nil
+	}
+}
+
+// WithMaxDownloadSize limits downloads to `size` bytes. The limit applies to the response as well as to
+// the decompressed content. Downloads exceeding it fail with `ErrDownloadTooLarge` and the local file
+// is left untouched. By default the size is unlimited.
+func WithMaxDownloadSize(size int64) Option {
+	return func(rl *RemoteList) error {
+		rl.maxSize = size
+		return nil
+	}
+}
+
+// WithFastHas lowercases all records when they are loaded or added, so Has can answer with a single
+// map lookup (`LowercaseHasFunc`) instead of comparing every record. List and Search return the
+// lowercased records. A custom HasFunc set with WithHasFunc is used as-is.
+func WithFastHas() Option {
+	return func(rl *RemoteList) error {
+		rl.lowercase = true
+		return nil
+	}
+}
+
+// WithDomains treats the records as hostnames: they are lowercased and stripped of a trailing dot
+// when they are loaded or added, which HasDomain relies on. Like WithFastHas, Has then answers with
+// a single map lookup and it can't be combined with WithCaseSensitive. Use WithNormalizeFunc(Punycode)
+// to match internationalized hostnames regardless of whether they are spelled in Unicode or punycode.
+func WithDomains() Option {
+	return func(rl *RemoteList) error {
+		rl.lowercase = true
+		rl.domains = true
+		return nil
+	}
+}
+
+// WithNormalizeFunc applies `fns` in the given order to every record when it is loaded or added and to the
+// terms passed to Has, HasBatch, HasPrefix, HasSuffix, HasDomain, Search and SearchN before the configured
+// functions run, so records and queries are always compared in the same form (see Lowercase, TrimDot, StripScheme and Punycode).
+func WithNormalizeFunc(fns ...NormalizeFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnNormalize = func(value string) string {
+			for _, fn := range fns {
+				value = fn(value)
+			}
+			return value
+		}
+		return nil
+	}
+}
+
+// WithPrefixIndex maintains a prefix tree of the (lowercased) records, so HasPrefix answers
+// in O(len(prefix)) instead of scanning all records. The index replaces the HasPrefixFunc
+// and costs additional memory. It is rebuilt whenever the list is reloaded.
+func WithPrefixIndex() Option {
+	return func(rl *RemoteList) error {
+		rl.indexPrefix = true
+		return nil
+	}
+}
+
+// WithSuffixIndex maintains a prefix tree of the reversed (lowercased) records, so HasSuffix answers
+// in O(len(suffix)) instead of scanning all records, e.g. to check hostnames against a domain list.
+// The index replaces the HasSuffixFunc and costs additional memory. It is rebuilt whenever the list is reloaded.
+func WithSuffixIndex() Option {
+	return func(rl *RemoteList) error {
+		rl.indexSuffix = true
+		return nil
+	}
+}
+
+// WithBloomFilter puts a Bloom filter of the (lowercased) records in front of Has, so most lookups of values
+// that are not in the list return `false` without looking at the records. Hits still go through the HasFunc.
+// The filter is sized for the number of records and the false-positive `rate` (e.g. 0.01), which costs about
+// 1.44*log2(1/rate) bits per record, i.e. 9.6 bits at 1% and 14.4 bits at 0.1%. Records added with Add are
+// inserted into the filter, but removed ones can't be taken out, so it is rebuilt whenever the list is reloaded.
+// It can't be combined with WithHasFunc.
+func WithBloomFilter(rate float64) Option {
+	return func(rl *RemoteList) error {
+		if rate <= 0 || rate >= 1 {
+			return fmt.Errorf("invalid false-positive rate: %g", rate)
+		}
+		rl.bloomRate = rate
+		return nil
+	}
+}
+
+// WithShards splits the records into `n` maps (e.g. 32) that are locked separately, instead of a single map
+// behind the lock of the RemoteList. Has, Add and Remove then only lock the shard of their value, so many
+// concurrent Adds (e.g. from a live feed) don't block lookups, while Search, List and the other scans walk all
+// shards. Refreshes still replace all shards at once.
+//
+// Has answers with a single map lookup, so WithFastHas or WithCaseSensitive is required. Sharding can't be
+// combined with WithHasFunc, the indexes (WithPrefixIndex, WithSuffixIndex, WithIPIndex) or WithBloomFilter.
+// Without this option the records are kept in a single map.
+func WithShards(n int) Option {
+	return func(rl *RemoteList) error {
+		if n < 1 {
+			return fmt.Errorf("invalid number of shards: %d", n)
+		}
+		rl.shardCount = n
+		return nil
+	}
+}
+
+// WithSortedStorage keeps the records in a sorted slice instead of a map, which takes roughly half the memory
+// for lists that rarely change between refreshes. Has and HasPrefix use a binary search (O(log n)) instead of
+// scanning all records. Add and Remove have to move the records behind the value, so they cost O(n) and are
+// meant for occasional changes only.
+//
+// Lookups compare the stored form, so WithFastHas or WithCaseSensitive is required. It can't be combined
+// with custom functions (WithHasFunc, WithHasPrefixFunc, WithHasSuffixFunc, WithSearchFunc) or WithShards.
+func WithSortedStorage() Option {
+	return func(rl *RemoteList) error {
+		rl.sortRecords = true
+		return nil
+	}
+}
+
+// WithIPIndex treats the list as an IP list: every record must be a network in CIDR notation
+// (e.g. "203.0.113.0/24") or a single IPv4 or IPv6 address, invalid records are skipped and counted in Stats.
+// The networks are kept in a radix tree, so HasIP and HasAddr answer without scanning all records.
+// It is rebuilt whenever the list is reloaded.
+func WithIPIndex() Option {
+	return func(rl *RemoteList) error {
+		rl.indexIP = true
+		return nil
+	}
+}
+
+// WithUnicodeFolding compares records with full Unicode case folding instead of strings.ToLower, e.g. "STRASSE"
+// matches "straße", and normalizes records and search terms to NFC, so composed and decomposed accents ("café")
+// match. With language.Und the folding is language-independent, other languages use their own lowercasing
+// rules instead, e.g. language.Turkish lowercases "I" to "ı" and "İ" to "i". With WithFastHas the records are
+// case-folded when they are loaded, with WithCaseSensitive only the NFC normalization applies.
+// The default functions, the indexes and the searches use the folding, which makes scans slower.
+func WithUnicodeFolding(lang language.Tag) Option {
+	return func(rl *RemoteList) error {
+		rl.unicode = true
+		rl.foldLanguage = lang
+		return nil
+	}
+}
+
+// WithCaseSensitive makes the default functions, the indexes and the glob and paginated searches
+// compare case-sensitively. Has then answers with a single map lookup (`CaseSensitiveHasFunc`).
+// It can't be combined with WithFastHas, which lowercases the records.
+func WithCaseSensitive() Option {
+	return func(rl *RemoteList) error {
+		rl.sensitive = true
+		return nil
+	}
+}
+
+// WithLocalOverrides merges the records of the local file at `path` into the list whenever it is loaded,
+// e.g. a hand-curated file of exceptions. The file is parsed with the DataLineFunc after the downloaded
+// content and is never written to. A missing file is not an error.
+func WithLocalOverrides(path string) Option {
+	return func(rl *RemoteList) error {
+		rl.overrides = append(rl.overrides, path)
+		return nil
+	}
+}
+
+// WithJSONMetadata makes MarshalJSON encode the RemoteList as an object that holds the remote location,
+// the location the list was downloaded from and the time of the last download besides the records.
+func WithJSONMetadata() Option {
+	return func(rl *RemoteList) error {
+		rl.jsonMeta = true
+		return nil
+	}
+}
+
+// WithOnChange sets a function that is called after a refresh changed the records. It is not called
+// for the initial load or for refreshes that don't change anything, e.g. because the list was not modified.
+func WithOnChange(fn OnChangeFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnChange = fn
+		return nil
+	}
+}
+
+// WithProgress sets a function that is called while a list is downloaded, every 256 KiB or every second and
+// once the download is complete. It runs in the downloading goroutine without holding any lock of the RemoteList.
+func WithProgress(fn ProgressFunc) Option {
+	return func(rl *RemoteList) error {
+		rl.fnProgress = fn
+		return nil
+	}
+}
+
+// WithLogger logs downloads, fallbacks to the local file and loads to `logger`, including the number of
+// lines the DataLineFunc rejected on each load. By default the RemoteList doesn't log anything.
+func WithLogger(logger *slog.Logger) Option {
+	return func(rl *RemoteList) error {
+		rl.logger = logger
+		return nil
+	}
+}
+
+// WithMaxLineLength sets the maximum length of a line of the local file in bytes, the default is DefaultMaxLineLength.
+// The file is read line by line, so this is the size of the largest buffer needed to read it.
+// Loading a file with a longer line fails.
+func WithMaxLineLength(n int) Option {
+	return func(rl *RemoteList) error {
+		if n <= 0 {
+			return fmt.Errorf("invalid maximum line length: %d", n)
+		}
+		rl.maxLine = n
+		return nil
+	}
+}
+
+// WithCSV parses the local file as CSV (using encoding/csv, so quoted fields may contain separators)
+// and uses the value of the zero-based `column` of each row as raw record, which is then passed through
+// the DataLineFunc. If `hasHeader` is `true`, the first row is skipped. `comma` is the field separator.
+// Malformed rows and rows without the column are skipped and counted in Stats.
+func WithCSV(column int, hasHeader bool, comma rune) Option {
+	return func(rl *RemoteList) error {
+		if column < 0 {
+			return fmt.Errorf("invalid CSV column: %d", column)
+		}
+		rl.split = splitCSV(column, hasHeader, comma)
+		return nil
+	}
+}
+
+// WithHostsFile parses the local file in the /etc/hosts format (`0.0.0.0 ads.example.com ads2.example.com # comment`)
+// and uses every hostname of each line that is not localhost or broadcast boilerplate as raw record, which is then
+// passed through the DataLineFunc. Unlike HostsFileLineFunc, lines with multiple hostnames yield all of them.
+// It can't be combined with WithCSV or WithJSON.
+func WithHostsFile() Option {
+	return func(rl *RemoteList) error {
+		rl.hostsFile = true
+		return nil
+	}
+}
+
+// WithJSON decodes the local file either as a JSON array or as JSON lines and uses the value at
+// `fieldPath` of each element as raw record, which is then passed through the DataLineFunc.
+// The path consists of object keys separated by dots (e.g. "data.host"), for an array of strings
+// it must be empty. The file is decoded as a stream, so large files are not read into memory at once.
+// Elements without the field are skipped and counted in Stats.
+func WithJSON(fieldPath string) Option {
+	return func(rl *RemoteList) error {
+		rl.split = splitJSON(fieldPath)
+		return nil
+	}
+}
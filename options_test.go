@@ -0,0 +1,445 @@
+package remotelist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPClient(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "a.com\n")
+	}))
+	defer srv.Close()
+
+	// The test server's certificate is only trusted by its own client
+	if _, err := NewWithOptions("", srv.URL); err == nil {
+		t.Fatal("download with the default client succeeded")
+	}
+	rl := newTestList(t, srv.URL, WithHTTPClient(srv.Client()))
+	if !rl.Has("a.com") {
+		t.Fatalf("List() = %v, want [a.com]", rl.List())
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	var keys, auths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("X-Api-Key"))
+		auths = append(auths, r.Header.Get("Authorization"))
+		fmt.Fprint(w, "a.com\n")
+	}))
+	defer srv.Close()
+
+	rl := newTestList(t, srv.URL,
+		WithHeaders(http.Header{"X-Api-Key": {"secret"}}),
+		WithRequestModifier(func(req *http.Request) { req.Header.Set("Authorization", "Bearer token") }),
+	)
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if len(keys) != 2 {
+		t.Fatalf("got %d requests, want 2", len(keys))
+	}
+	for i := range keys {
+		if keys[i] != "secret" || auths[i] != "Bearer token" {
+			t.Errorf("request %d: X-Api-Key = %q, Authorization = %q", i, keys[i], auths[i])
+		}
+	}
+}
+
+func TestWithDownloadTimeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Headers and the first record arrive in time, the rest of the body stalls
+		fmt.Fprint(w, "a.com\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		fmt.Fprint(w, "b.com\n")
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), srv.URL, WithDownloadTimeout(50*time.Millisecond))
+	if !errors.Is(err, ErrDownloadTimeout) {
+		t.Fatalf("NewWithOptions: got %v, want ErrDownloadTimeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("download was aborted after %s", elapsed)
+	}
+}
+
+func TestWithMaxDownloadSize(t *testing.T) {
+	// The limit applies to the transferred bytes, gzip makes this short list larger than the limit
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	io.WriteString(zw, "a.com\n")
+	zw.Close()
+	sum := sha256.Sum256(buf.Bytes())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Without a Content-Length the limit can only be enforced while reading
+		w.Header().Set("Transfer-Encoding", "chunked")
+		w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"with checksum", []Option{WithSHA256(hex.EncodeToString(sum[:]))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]Option{WithMaxDownloadSize(16)}, tt.opts...)
+			_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), srv.URL, opts...)
+			if !errors.Is(err, ErrDownloadTooLarge) {
+				t.Errorf("NewWithOptions: got %v, want ErrDownloadTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestWithUserAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, DefaultUserAgent},
+		{"custom", []Option{WithUserAgent("blocklist-sync/2.0")}, "blocklist-sync/2.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var agents []string
+			conditional := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				agents = append(agents, r.UserAgent())
+				if r.Header.Get("If-None-Match") == `"v1"` {
+					conditional++
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				w.Header().Set("ETag", `"v1"`)
+				fmt.Fprint(w, "a.com\n")
+			}))
+			defer srv.Close()
+
+			rl := newTestList(t, srv.URL, tt.opts...)
+			if err := rl.Refresh(true); err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+			if len(agents) != 2 || conditional != 1 {
+				t.Fatalf("got %d requests of which %d conditional, want 2 and 1", len(agents), conditional)
+			}
+			for i, ua := range agents {
+				if ua != tt.want {
+					t.Errorf("request %d: User-Agent = %q, want %q", i, ua, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestWithProxy(t *testing.T) {
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A forward proxy receives the absolute URL of the list
+		hosts = append(hosts, r.URL.Host)
+		fmt.Fprint(w, "a.com\n")
+	}))
+	defer proxy.Close()
+
+	rl := newTestList(t, "http://lists.invalid/list.txt", WithProxy(proxy.URL))
+	if !rl.Has("a.com") || len(hosts) != 1 || hosts[0] != "lists.invalid" {
+		t.Errorf("got records %q via the proxy for hosts %q", rl.List(), hosts)
+	}
+
+	for _, proxyURL := range []string{"ftp://proxy:21", "http://", "://proxy", "proxy:8080"} {
+		if _, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), proxy.URL, WithProxy(proxyURL)); err == nil {
+			t.Errorf("WithProxy(%q) was accepted", proxyURL)
+		}
+	}
+}
+
+func TestWithMaxAge(t *testing.T) {
+	t.Run("RefreshAlways", func(t *testing.T) {
+		remote := newTestRemote(t, "a.com\n")
+		rl := newTestList(t, remote.URL, WithMaxAge(RefreshAlways))
+		for range 2 {
+			if err := rl.Refresh(false); err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+		}
+		if n := remote.hits.Load(); n != 3 {
+			t.Errorf("got %d requests, want 3", n)
+		}
+	})
+
+	t.Run("RefreshNever", func(t *testing.T) {
+		remote := newTestRemote(t, "a.com\n")
+		local := filepath.Join(t.TempDir(), "list.txt")
+
+		// Without a local file the list is downloaded once
+		rl, err := NewSimple(local, remote.URL, RefreshNever)
+		if err != nil {
+			t.Fatalf("NewSimple: %v", err)
+		}
+		defer rl.Close()
+		old := time.Now().Add(-365 * 24 * time.Hour)
+		if err := os.Chtimes(local, old, old); err != nil {
+			t.Fatal(err)
+		}
+		if err := rl.Refresh(false); err != nil {
+			t.Fatalf("Refresh: %v", err)
+		}
+
+		// An existing local file is used as it is, no matter how old
+		remote.set("b.com\n")
+		again, err := NewSimple(local, remote.URL, RefreshNever)
+		if err != nil {
+			t.Fatalf("NewSimple: %v", err)
+		}
+		defer again.Close()
+		if n := remote.hits.Load(); n != 1 || !again.Has("a.com") {
+			t.Errorf("got %d requests and records %q, want 1 and [a.com]", n, again.List())
+		}
+
+		// Forced refreshes still download the list
+		if err := again.Refresh(true); err != nil || !again.Has("b.com") {
+			t.Errorf("forced Refresh: %v, records %q", err, again.List())
+		}
+	})
+
+	t.Run("negative", func(t *testing.T) {
+		remote := newTestRemote(t, "a.com\n")
+		if _, err := NewSimple(filepath.Join(t.TempDir(), "list.txt"), remote.URL, -time.Second); err == nil {
+			t.Error("negative max age was accepted")
+		}
+		if n := remote.hits.Load(); n != 0 {
+			t.Errorf("got %d requests for an invalid configuration", n)
+		}
+	})
+}
+
+func TestWithMirrors(t *testing.T) {
+	primary := newTestRemote(t, "a.com\n")
+	primary.status.Store(http.StatusInternalServerError)
+	broken := newTestRemote(t, "")
+	broken.status.Store(http.StatusNotFound)
+	mirror := newTestRemote(t, "b.com\n")
+
+	// The mirrors are tried in order until one of them succeeds
+	rl := newTestList(t, primary.URL, WithMirrors(broken.URL, mirror.URL))
+	if !rl.Has("b.com") || rl.Source() != mirror.URL {
+		t.Fatalf("got records %q from %q, want [b.com] from the second mirror", rl.List(), rl.Source())
+	}
+
+	// The primary location is preferred again once it works
+	primary.status.Store(http.StatusOK)
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if !rl.Has("a.com") || rl.Source() != primary.URL {
+		t.Errorf("got records %q from %q, want [a.com] from the primary location", rl.List(), rl.Source())
+	}
+
+	// If all locations fail, every error is reported
+	primary.status.Store(http.StatusInternalServerError)
+	mirror.status.Store(http.StatusInternalServerError)
+	_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), primary.URL, WithMirrors(broken.URL, mirror.URL))
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || !strings.Contains(err.Error(), broken.URL) || !strings.Contains(err.Error(), mirror.URL) {
+		t.Errorf("NewWithOptions: got %v, want the errors of all locations", err)
+	}
+}
+
+func TestWithSHA256URL(t *testing.T) {
+	var mu sync.Mutex
+	body, etag := "a.com\n", `"v1"`
+	var checksums atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/list.txt.sha256" {
+			checksums.Add(1)
+			fmt.Fprintf(w, "%x  list.txt\n", sha256.Sum256([]byte(body)))
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+
+	rl := newTestList(t, srv.URL+"/list.txt", WithSHA256URL(srv.URL+"/list.txt.sha256"), WithMaxAge(RefreshAlways))
+	if !rl.Has("a.com") || checksums.Load() != 1 {
+		t.Fatalf("got records %q after %d checksum requests, want [a.com] after 1", rl.List(), checksums.Load())
+	}
+
+	// An unchanged list has nothing to verify
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if n := checksums.Load(); n != 1 {
+		t.Errorf("got %d checksum requests after a 304, want 1", n)
+	}
+
+	mu.Lock()
+	body, etag = "b.com\n", `"v2"`
+	mu.Unlock()
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if n := checksums.Load(); n != 2 || !rl.Has("b.com") {
+		t.Errorf("got records %q after %d checksum requests, want [b.com] after 2", rl.List(), n)
+	}
+}
+
+func TestWithoutLocalFile(t *testing.T) {
+	var mu sync.Mutex
+	body, etag := "a.com\nb.com\n", `"v1"`
+	var conditional atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Header.Get("If-None-Match") == etag {
+			conditional.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		fmt.Fprint(w, body)
+	}))
+	defer srv.Close()
+	set := func(b, e string) {
+		mu.Lock()
+		body, etag = b, e
+		mu.Unlock()
+	}
+
+	rl, err := NewWithOptions("", srv.URL, WithMaxAge(RefreshAlways), WithValidator(MinRecords(2)))
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	if got := rl.List(); !slices.Equal(got, []string{"a.com", "b.com"}) {
+		t.Fatalf("List() = %q, want [a.com b.com]", got)
+	}
+
+	// The download is parsed, not stored
+	if fi, err := rl.storage.Stat(rl.fileLocal); err != nil || fi.Size() != 0 {
+		t.Errorf("stored %v bytes (%v), want an empty local file", fi, err)
+	}
+
+	// The validators are kept for conditional requests
+	if err := rl.Refresh(false); err != nil || conditional.Load() != 1 || rl.Len() != 2 {
+		t.Errorf("Refresh: %v, %d conditional requests, records %q", err, conditional.Load(), rl.List())
+	}
+
+	// A rejected download doesn't replace the records, although it has been parsed
+	set("c.com\n", `"v2"`)
+	if err := rl.Refresh(false); !errors.Is(err, ErrValidation) {
+		t.Errorf("Refresh: got %v, want ErrValidation", err)
+	}
+	if got := rl.List(); !slices.Equal(got, []string{"a.com", "b.com"}) {
+		t.Errorf("List() after a rejected download = %q, want [a.com b.com]", got)
+	}
+
+	set("c.com\nd.com\n", `"v3"`)
+	if err := rl.Refresh(false); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := rl.List(); !slices.Equal(got, []string{"c.com", "d.com"}) {
+		t.Errorf("List() = %q, want [c.com d.com]", got)
+	}
+}
+
+func TestWithHeadCheck(t *testing.T) {
+	tests := []struct {
+		name         string
+		lastModified string
+		wantGets     int32
+		want         string
+	}{
+		{"unchanged Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT", 1, "a.com"},
+		{"without Last-Modified", "", 2, "b.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			body := "a.com\n"
+			var heads, gets atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if tt.lastModified != "" {
+					w.Header().Set("Last-Modified", tt.lastModified)
+				}
+				w.Header().Set("Content-Length", fmt.Sprint(len(body)))
+				if r.Method == http.MethodHead {
+					heads.Add(1)
+					return
+				}
+				gets.Add(1)
+				fmt.Fprint(w, body)
+			}))
+			defer srv.Close()
+
+			rl := newTestList(t, srv.URL, WithHeadCheck(), WithMaxAge(RefreshAlways))
+
+			// The list changes but keeps its size
+			mu.Lock()
+			body = "b.com\n"
+			mu.Unlock()
+			if err := rl.Refresh(false); err != nil {
+				t.Fatalf("Refresh: %v", err)
+			}
+			if n := gets.Load(); n != tt.wantGets || !rl.Has(tt.want) {
+				t.Errorf("got %d downloads and records %q, want %d and [%s]", n, rl.List(), tt.wantGets, tt.want)
+			}
+			if tt.lastModified != "" && heads.Load() != 1 {
+				t.Errorf("got %d HEAD requests, want 1", heads.Load())
+			}
+		})
+	}
+}
+
+func TestWithMaxShrink(t *testing.T) {
+	remote := newTestRemote(t, strings.Join(testDomains(10), "\n"))
+	rl := newTestList(t, remote.URL, WithMaxShrink(50))
+
+	// Records added locally don't make an unchanged list look shrunk
+	rl.AddAll(testDomains(100)[10:])
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh of the unchanged list: %v", err)
+	}
+
+	remote.set(strings.Join(testDomains(4), "\n"))
+	if err := rl.Refresh(true); !errors.Is(err, ErrValidation) {
+		t.Errorf("Refresh: got %v, want ErrValidation", err)
+	}
+	if n := rl.Len(); n != 100 {
+		t.Errorf("Len() = %d after a rejected download, want 100", n)
+	}
+}
@@ -0,0 +1,43 @@
+package remotelist
+
+import "strings"
+
+// LongestPrefixMatch returns the longest record that `value` starts with, e.g. "/api/admin/" for "/api/admin/users"
+// if the records are "/api/" and "/api/admin/". Matching is case-insensitive unless WithCaseSensitive is used.
+// With WithPrefixIndex this is a single descent of the index, otherwise all records are scanned.
+func (rl *RemoteList) LongestPrefixMatch(value string) (record string, ok bool) {
+	value = rl.fold(rl.query(value))
+	rl.rlock()
+	defer rl.mu.RUnlock()
+
+	if rl.prefixes != nil {
+		n, found := rl.prefixes.longestPrefix(value)
+		if !found {
+			return "", false
+		}
+		// The index only knows the case-folded record
+		if rl.hasRecord(value[:n]) {
+			return value[:n], true
+		}
+		return rl.longestPrefixScan(value, n)
+	}
+	return rl.longestPrefixScan(value, -1)
+}
+
+// longestPrefixScan scans the records for the longest one that `value` starts with (or, if `length` is not -1,
+// for one of that length). Of records that only differ in case, the smallest is returned.
+// The caller must hold the read lock.
+func (rl *RemoteList) longestPrefixScan(value string, length int) (record string, ok bool) {
+	best := -1
+	rl.eachRecord(func(rec string) bool {
+		folded := rl.fold(rec)
+		if (length >= 0 && len(folded) != length) || len(folded) < best || !strings.HasPrefix(value, folded) {
+			return true
+		}
+		if len(folded) > best || rec < record {
+			record, best = rec, len(folded)
+		}
+		return true
+	})
+	return record, best >= 0
+}
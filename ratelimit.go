@@ -0,0 +1,91 @@
+package remotelist
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+)
+
+// RateLimiter limits the bandwidth of downloads with a token bucket that holds up to one second worth of bytes.
+// A RateLimiter can be shared by multiple RemoteLists (see WithSharedRateLimiter) to cap their aggregate bandwidth.
+type RateLimiter struct {
+	mu     *sync.Mutex
+	rate   float64   // Bytes per second
+	tokens float64   // Bytes that may be read right now, negative if readers are waiting
+	last   time.Time // Time the tokens were last refilled
+}
+
+// NewRateLimiter creates a RateLimiter that allows `bytesPerSec` bytes per second
+func NewRateLimiter(bytesPerSec int64) *RateLimiter {
+	return &RateLimiter{
+		mu:     &sync.Mutex{},
+		rate:   float64(bytesPerSec),
+		tokens: float64(bytesPerSec),
+		last:   time.Now(),
+	}
+}
+
+// wait takes `n` bytes from the bucket and waits until they are covered by the rate
+func (l *RateLimiter) wait(ctx context.Context, n int) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.tokens+now.Sub(l.last).Seconds()*l.rate, l.rate)
+	l.last = now
+	l.tokens -= float64(n)
+	deficit := -l.tokens
+	l.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(time.Duration(deficit / l.rate * float64(time.Second)))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	case <-timer.C:
+		return nil
+	}
+}
+
+// rateReader reads from `r` no faster than all `limiters` allow
+type rateReader struct {
+	ctx      context.Context
+	r        io.Reader
+	limiters []*RateLimiter
+	chunk    int // Maximum number of bytes per read, so a single read doesn't exceed the bucket
+}
+
+func (rr *rateReader) Read(p []byte) (int, error) {
+	if len(p) > rr.chunk {
+		p = p[:rr.chunk]
+	}
+	n, err := rr.r.Read(p)
+	for _, l := range rr.limiters {
+		if errWait := l.wait(rr.ctx, n); errWait != nil {
+			return n, errWait
+		}
+	}
+	return n, err
+}
+
+// rateLimit wraps `r` so it is read no faster than the configured rate limits allow
+func (rl *RemoteList) rateLimit(ctx context.Context, r io.Reader) io.Reader {
+	rl.mu.RLock()
+	limiters := make([]*RateLimiter, 0, 2)
+	for _, l := range []*RateLimiter{rl.limiter, rl.sharedLimiter} {
+		if l != nil {
+			limiters = append(limiters, l)
+		}
+	}
+	rl.mu.RUnlock()
+	if len(limiters) == 0 {
+		return r
+	}
+	chunk := 32 * 1024
+	for _, l := range limiters {
+		chunk = max(min(chunk, int(l.rate)), 1)
+	}
+	return &rateReader{ctx: ctx, r: r, limiters: limiters, chunk: chunk}
+}
@@ -0,0 +1,58 @@
+package remotelist
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rateLimitedBody is 30000 bytes, which takes half a second at 20000 bytes per second after the bucket is drained
+var rateLimitedBody = strings.Repeat(strings.Repeat("a", 59)+"\n", 500)
+
+func TestWithDownloadRateLimit(t *testing.T) {
+	remote := newTestRemote(t, rateLimitedBody)
+	start := time.Now()
+	rl := newTestList(t, remote.URL, WithDownloadRateLimit(20000))
+	elapsed := time.Since(start)
+	if rl.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1", rl.Len())
+	}
+	if elapsed < 400*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("download took %s, want about 500ms", elapsed)
+	}
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	m := NewManager(WithSharedRateLimiter(NewRateLimiter(40000)))
+	for _, name := range []string{"a", "b"} {
+		remote := newTestRemote(t, rateLimitedBody)
+		m.Add(name, newTestList(t, remote.URL, WithMaxAge(RefreshAlways)))
+	}
+
+	// Together the lists download 60000 bytes, the bucket covers the first 40000
+	start := time.Now()
+	if err := m.RefreshAll(context.Background()); err != nil {
+		t.Fatalf("RefreshAll: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("refreshing took %s, want about 500ms", elapsed)
+	}
+}
+
+func TestSharedRateLimiterRemove(t *testing.T) {
+	m := NewManager(WithSharedRateLimiter(NewRateLimiter(20000)))
+	remote := newTestRemote(t, rateLimitedBody)
+	rl := newTestList(t, remote.URL)
+	m.Add("a", rl)
+	m.Remove("a")
+
+	// The bucket covers the first 20000 bytes, a list that is still limited needs another 500ms
+	start := time.Now()
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("refreshing a removed list took %s", elapsed)
+	}
+}
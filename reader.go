@@ -0,0 +1,59 @@
+package remotelist
+
+import (
+	"fmt"
+	"io"
+)
+
+// NewFromReader creates a new RemoteList instance from the list read from `r` without downloading anything,
+// e.g. for data handed over by another system or for tests. The content is processed like a downloaded list:
+// it is run through the DataFilterFunc (or StreamFilterFunc) and each line through the DataLineFunc.
+// As there is no local file, the content is kept in memory (or in a temporary directory with
+// WithEphemeralCache) and a Storage set with WithStorage is not used. Refresh does nothing.
+func NewFromReader(r io.Reader, opts ...Option) (*RemoteList, error) {
+	rl, err := newRemoteList("", "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	rl.static = true
+
+	err = rl.storage.Write(rl.fileLocal, rl.localMode(), func(w io.Writer) error {
+		if rl.fnStreamFilter == nil {
+			_, err := io.Copy(w, r)
+			return err
+		}
+		return rl.fnStreamFilter(w, r)
+	})
+	if err != nil {
+		_ = rl.removeEphemeral()
+		return nil, fmt.Errorf("%w: %w", ErrReadLocal, err)
+	}
+	if err := rl.init(); err != nil {
+		_ = rl.removeEphemeral()
+		return nil, err
+	}
+	return rl, nil
+}
+
+// NewFromStrings creates a new RemoteList instance with the `values` as records without downloading anything,
+// e.g. for tests. The values are normalized but not run through the DataLineFunc. With WithIPIndex, values
+// that aren't valid networks are skipped and counted as malformed entries like in a loaded list.
+// Refresh does nothing.
+func NewFromStrings(values []string, opts ...Option) (*RemoteList, error) {
+	rl, err := newRemoteList("", "", opts...)
+	if err != nil {
+		return nil, err
+	}
+	rl.static = true
+
+	records := make(map[string]struct{}, len(values))
+	for _, v := range values {
+		records[rl.normalize(v)] = struct{}{}
+	}
+	malformed := rl.dropInvalidNetworks(records, nil)
+	rl.setRecords(records, map[string]struct{}{}, nil)
+	rl.mu.Lock()
+	rl.malformed = malformed
+	rl.mu.Unlock()
+	return rl, nil
+}
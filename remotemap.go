@@ -0,0 +1,221 @@
+package remotelist
+
+import (
+	"context"
+	"iter"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A recordStore stores the records parsed from the local file instead of the RemoteList.
+// This allows other types to reuse the download and refresh machinery of a RemoteList.
+type recordStore interface {
+	// parse parses `lines` into a fresh set of records. The returned `commit` function swaps them in.
+	// It also returns the number of records and the number of rejected lines.
+	parse(lines iter.Seq[string]) (commit func(), records, rejected int)
+}
+
+// A `KeyValueLineFunc` is run over each line of the locally stored file of a RemoteMap.
+//
+// It splits the line into a key and a value and can exclude lines from the index (`include = false`).
+type KeyValueLineFunc func(line string) (key, value string, include bool)
+
+// The default `KeyValueLine` function drops lines like DefaultDataLineProcessFunc does and splits the others
+// at the first comma, tab or space, e.g. `1.2.3.4 phishing` or `example.com,ads`. Key and value are trimmed.
+// Lines without a separator are included with an empty value.
+var DefaultKeyValueLineFunc = func(line string) (key, value string, include bool) {
+	line, include = DefaultDataLineProcessFunc(line)
+	if !include {
+		return "", "", false
+	}
+	if i := strings.IndexAny(line, ",\t "); i >= 0 {
+		return strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:]), true
+	}
+	return strings.TrimSpace(line), "", true
+}
+
+// SearchField selects what RemoteMap.Search matches against
+type SearchField int
+
+const (
+	SearchKeys   SearchField = 1 << iota // Match against the keys
+	SearchValues                         // Match against the values
+)
+
+// SearchBoth matches against keys and values
+const SearchBoth = SearchKeys | SearchValues
+
+// RemoteMap is like a RemoteList, but each record maps a key to a value.
+// It uses the same download and refresh machinery as RemoteList.
+//
+// Keys are matched case-insensitively unless WithCaseSensitive is used, so they are stored lowercased.
+type RemoteMap struct {
+	list   *RemoteList
+	fnLine KeyValueLineFunc // Function for splitting each line into key and value
+	mu     *sync.RWMutex
+	values map[string]string // values stores the data from the list file by key
+}
+
+// NewMap creates a new RemoteMap instance that downloads `fileRemote` to `fileLocal` and splits each line into
+// key and value with `fnLine`. If `fnLine` is `nil`, `DefaultKeyValueLineFunc` is used.
+// The options are the same as for NewWithOptions; options that configure the records of a RemoteList
+// (e.g. the DataLineFunc or the indexes) have no effect.
+func NewMap(fileLocal, fileRemote string, fnLine KeyValueLineFunc, opts ...Option) (*RemoteMap, error) {
+	return NewMapContext(context.Background(), fileLocal, fileRemote, fnLine, opts...)
+}
+
+// NewMapContext is like NewMap but aborts the initial download when `ctx` is canceled
+func NewMapContext(ctx context.Context, fileLocal, fileRemote string, fnLine KeyValueLineFunc, opts ...Option) (*RemoteMap, error) {
+	rl, err := newRemoteList(fileLocal, fileRemote, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	rm := &RemoteMap{
+		list:   rl,
+		fnLine: fnLine,
+		mu:     &sync.RWMutex{},
+		values: map[string]string{},
+	}
+	if rm.fnLine == nil {
+		rm.fnLine = DefaultKeyValueLineFunc
+	}
+	rl.store = rm
+
+	if err := rl.start(ctx); err != nil {
+		return nil, err
+	}
+	return rm, nil
+}
+
+// parse implements recordStore
+func (rm *RemoteMap) parse(lines iter.Seq[string]) (commit func(), records, rejected int) {
+	values := map[string]string{}
+	for line := range lines {
+		key, value, ok := rm.fnLine(line)
+		if !ok {
+			rejected++
+			continue
+		}
+		values[rm.key(key)] = value
+	}
+	return func() {
+		rm.mu.Lock()
+		rm.values = values
+		rm.mu.Unlock()
+	}, len(values), rejected
+}
+
+// key returns `key` in the form it is stored in
+func (rm *RemoteMap) key(key string) string {
+	return rm.list.fold(rm.list.normalize(key))
+}
+
+// Get returns the value stored for `key`
+func (rm *RemoteMap) Get(key string) (value string, ok bool) {
+	rm.list.lazyInit()
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	value, ok = rm.values[rm.key(key)]
+	return value, ok
+}
+
+// Has checks if `key` exists in the RemoteMap
+func (rm *RemoteMap) Has(key string) bool {
+	_, ok := rm.Get(key)
+	return ok
+}
+
+// Len returns the number of keys in the RemoteMap
+func (rm *RemoteMap) Len() int {
+	rm.list.lazyInit()
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	return len(rm.values)
+}
+
+// Keys returns all keys of the RemoteMap as a sorted string slice
+func (rm *RemoteMap) Keys() []string {
+	rm.list.lazyInit()
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	res := make([]string, 0, len(rm.values))
+	for key := range rm.values {
+		res = append(res, key)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Search returns the sorted keys of all entries whose key and/or value (depending on `field`) contains `term`.
+// Matching is case-insensitive unless WithCaseSensitive is used.
+func (rm *RemoteMap) Search(term string, field SearchField) []string {
+	term = rm.list.fold(term)
+	rm.list.lazyInit()
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	res := []string{}
+	for key, value := range rm.values {
+		if (field&SearchKeys != 0 && strings.Contains(key, term)) ||
+			(field&SearchValues != 0 && strings.Contains(rm.list.fold(value), term)) {
+			res = append(res, key)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Refresh downloads the list again if the local file is older than maxAge (or always if `force` is `true`)
+// and replaces the entries with the freshly parsed ones. See RemoteList.Refresh.
+func (rm *RemoteMap) Refresh(force bool) error {
+	return rm.list.Refresh(force)
+}
+
+// RefreshContext is like Refresh but aborts the download when `ctx` is canceled.
+func (rm *RemoteMap) RefreshContext(ctx context.Context, force bool) error {
+	return rm.list.RefreshContext(ctx, force)
+}
+
+// EnsureLoaded loads the list unless it has been loaded already. See RemoteList.EnsureLoaded.
+func (rm *RemoteMap) EnsureLoaded(ctx context.Context) error {
+	return rm.list.EnsureLoaded(ctx)
+}
+
+// StartAutoRefresh starts a goroutine that calls Refresh every `interval`. See RemoteList.StartAutoRefresh.
+func (rm *RemoteMap) StartAutoRefresh(interval time.Duration) {
+	rm.list.StartAutoRefresh(interval)
+}
+
+// Stop stops the auto-refresh goroutine (if any) and waits for it to exit.
+func (rm *RemoteMap) Stop() {
+	rm.list.Stop()
+}
+
+// Close stops all background work and aborts a download that is in progress. See RemoteList.Close.
+func (rm *RemoteMap) Close() error {
+	return rm.list.Close()
+}
+
+// LastError returns the error of the most recent refresh or `nil` if it succeeded.
+func (rm *RemoteMap) LastError() error {
+	return rm.list.LastError()
+}
+
+// IsStale returns `true` if the records are based on an outdated local file. See RemoteList.IsStale.
+func (rm *RemoteMap) IsStale() bool {
+	return rm.list.IsStale()
+}
+
+// LastRefresh returns the time of the last refresh that loaded up-to-date records. See RemoteList.LastRefresh.
+func (rm *RemoteMap) LastRefresh() time.Time {
+	return rm.list.LastRefresh()
+}
+
+// Stats returns the current state of the RemoteMap
+func (rm *RemoteMap) Stats() Stats {
+	stats := rm.list.Stats()
+	stats.RecordCount = rm.Len()
+	return stats
+}
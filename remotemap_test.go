@@ -0,0 +1,87 @@
+package remotelist
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestMap(t *testing.T, remote string, fnLine KeyValueLineFunc, opts ...Option) *RemoteMap {
+	t.Helper()
+	rm, err := NewMap(filepath.Join(t.TempDir(), "list.txt"), remote, fnLine, opts...)
+	if err != nil {
+		t.Fatalf("NewMap: %v", err)
+	}
+	t.Cleanup(func() { rm.Close() })
+	return rm
+}
+
+func TestRemoteMap(t *testing.T) {
+	remote := newTestRemote(t, "# feed\n1.2.3.4 phishing\nExample.com,ads\nbad.org\tPhishing Kit\nbare.net\n")
+	rm := newTestMap(t, remote.URL, nil)
+
+	tests := []struct {
+		key   string
+		value string
+		ok    bool
+	}{
+		{"1.2.3.4", "phishing", true},
+		{"example.com", "ads", true},
+		{"EXAMPLE.COM", "ads", true},
+		{"bad.org", "Phishing Kit", true},
+		{"bare.net", "", true},
+		{"# feed", "", false},
+		{"missing.com", "", false},
+	}
+	for _, tt := range tests {
+		value, ok := rm.Get(tt.key)
+		if value != tt.value || ok != tt.ok {
+			t.Errorf("Get(%q) = %q, %v, want %q, %v", tt.key, value, ok, tt.value, tt.ok)
+		}
+		if got := rm.Has(tt.key); got != tt.ok {
+			t.Errorf("Has(%q) = %v, want %v", tt.key, got, tt.ok)
+		}
+	}
+	if n := rm.Len(); n != 4 {
+		t.Errorf("Len() = %d, want 4", n)
+	}
+
+	searches := []struct {
+		term  string
+		field SearchField
+		want  []string
+	}{
+		{"phish", SearchKeys, []string{}},
+		{"phish", SearchValues, []string{"1.2.3.4", "bad.org"}},
+		{"ba", SearchKeys, []string{"bad.org", "bare.net"}},
+		{"a", SearchBoth, []string{"bad.org", "bare.net", "example.com"}},
+	}
+	for _, tt := range searches {
+		if got := rm.Search(tt.term, tt.field); !slices.Equal(got, tt.want) {
+			t.Errorf("Search(%q, %d) = %q, want %q", tt.term, tt.field, got, tt.want)
+		}
+	}
+
+	// Refreshes replace the entries
+	remote.set("1.2.3.4 malware\n")
+	if err := rm.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if value, _ := rm.Get("1.2.3.4"); value != "malware" || !slices.Equal(rm.Keys(), []string{"1.2.3.4"}) {
+		t.Errorf("got keys %q and value %q after refreshing", rm.Keys(), value)
+	}
+}
+
+func TestRemoteMapLineFunc(t *testing.T) {
+	remote := newTestRemote(t, "a.com=1\nb.com=2\nnot a pair\n")
+	rm := newTestMap(t, remote.URL, func(line string) (key, value string, include bool) {
+		return strings.Cut(line, "=")
+	}, WithCaseSensitive())
+	if value, ok := rm.Get("b.com"); !ok || value != "2" {
+		t.Errorf("Get(b.com) = %q, %v, want 2, true", value, ok)
+	}
+	if n, rejected := rm.Len(), rm.Stats().RejectedLines; n != 2 || rejected != 1 {
+		t.Errorf("got %d entries and %d rejected lines, want 2 and 1", n, rejected)
+	}
+}
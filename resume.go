@@ -0,0 +1,164 @@
+package remotelist
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// partialMeta holds what's needed to resume a partial download.
+// It is stored in a sidecar file next to the partial download.
+type partialMeta struct {
+	ETag         string `json:"etag,omitempty"`          // ETag header of the response
+	LastModified string `json:"last_modified,omitempty"` // Last-Modified header of the response
+	Size         int64  `json:"size"`                    // Total size of the list, -1 if unknown
+	AcceptRanges bool   `json:"accept_ranges"`           // Whether the server supports range requests
+}
+
+// partialFile returns the path of the file that holds the partial download of `fileLocal`
+func partialFile(fileLocal string) string {
+	return fileLocal + ".part"
+}
+
+// removePartial removes the partial download and its metadata
+func (rl *RemoteList) removePartial() {
+	part := partialFile(rl.fileLocal)
+	_ = os.Remove(part)
+	_ = os.Remove(metadataFile(part))
+}
+
+// partialOffset returns the size of the partial download of a previous attempt and the validator
+// to send with If-Range. The offset is 0 if there is nothing to resume.
+func (rl *RemoteList) partialOffset() (offset int64, validator string) {
+	part := partialFile(rl.fileLocal)
+	fileInfo, err := os.Stat(part)
+	if err != nil {
+		return 0, ""
+	}
+	meta := partialMeta{}
+	if data, err := os.ReadFile(metadataFile(part)); err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+	validator = meta.ETag
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = meta.LastModified
+	}
+	if !meta.AcceptRanges || validator == "" || fileInfo.Size() == 0 {
+		rl.removePartial()
+		return 0, ""
+	}
+	return fileInfo.Size(), validator
+}
+
+// contentRangeStart parses the Content-Range header of a 206 response and returns the first byte
+// and the total size of the list (-1 if unknown)
+func contentRangeStart(value string) (start, total int64, err error) {
+	rangeSpec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", value)
+	}
+	rangeSpec, size, _ := strings.Cut(rangeSpec, "/")
+	first, _, _ := strings.Cut(rangeSpec, "-")
+	if start, err = strconv.ParseInt(first, 10, 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range: %q", value)
+	}
+	total = -1
+	if size != "*" {
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("invalid Content-Range: %q", value)
+		}
+	}
+	return start, total, nil
+}
+
+// spool appends the body of `resp` to the partial download, or replaces it if the server sent the complete list,
+// and replaces the body with the complete partial download once it has been read. If reading the body fails,
+// the partial download is kept so the next attempt can resume it.
+func (rl *RemoteList) spool(ctx context.Context, resp *http.Response, offset int64) (*http.Response, error) {
+	defer resp.Body.Close()
+
+	part := partialFile(rl.fileLocal)
+	flag := os.O_CREATE | os.O_WRONLY
+	total := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		start, size, err := contentRangeStart(resp.Header.Get("Content-Range"))
+		if err != nil || start != offset {
+			rl.removePartial()
+			return nil, fmt.Errorf("%w, could not resume: %w", ErrDownloadFailed, errors.Join(err, fmt.Errorf("expected range from byte %d", offset)))
+		}
+		flag |= os.O_APPEND
+		total = size
+	} else {
+		// The server ignored the range, start over
+		flag |= os.O_TRUNC
+		offset = 0
+	}
+	if rl.maxSize > 0 && total > rl.maxSize {
+		rl.removePartial()
+		return nil, fmt.Errorf("%w: %d bytes exceed the limit of %d bytes", ErrDownloadTooLarge, total, rl.maxSize)
+	}
+
+	// A partial download that already reached the limit leaves no room for the rest
+	remaining := int64(0)
+	if rl.maxSize > 0 {
+		remaining = rl.maxSize - offset
+		if remaining <= 0 {
+			rl.removePartial()
+			return nil, fmt.Errorf("%w: the partial download of %d bytes reached the limit of %d bytes", ErrDownloadTooLarge, offset, rl.maxSize)
+		}
+	}
+
+	// Remember how to resume before reading the body, an interrupted download is what we want to resume
+	meta, err := json.Marshal(partialMeta{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         total,
+		AcceptRanges: resp.StatusCode == http.StatusPartialContent || resp.Header.Get("Accept-Ranges") == "bytes",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWriteLocal, err)
+	}
+	if err := os.WriteFile(metadataFile(part), meta, cmp.Or(rl.fileMode, 0644)); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWriteLocal, err)
+	}
+
+	f, err := os.OpenFile(part, flag, cmp.Or(rl.fileMode, 0644))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrWriteLocal, err)
+	}
+	body := &errReader{r: rl.progress(rl.rateLimit(ctx, resp.Body), offset, total)}
+	n, err := io.Copy(f, limitReader(body, remaining))
+	if errClose := f.Close(); err == nil {
+		err = errClose
+	}
+	switch {
+	case errors.Is(err, ErrDownloadTooLarge):
+		rl.removePartial()
+		return nil, err
+	case err != nil && errors.Is(context.Cause(ctx), ErrDownloadTimeout):
+		return nil, fmt.Errorf("%w after %s", ErrDownloadTimeout, rl.timeout)
+	case body.err != nil:
+		return nil, fmt.Errorf("%w, could not read response: %w", ErrDownloadFailed, err)
+	case err != nil:
+		return nil, fmt.Errorf("%w: %w", ErrWriteLocal, err)
+	}
+	if total >= 0 && offset+n != total {
+		return nil, fmt.Errorf("%w, incomplete response: got %d of %d bytes", ErrDownloadFailed, offset+n, total)
+	}
+
+	// The partial download is complete, it is removed once it has been processed
+	f, err = os.Open(part)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrReadLocal, err)
+	}
+	resp.Body = f
+	resp.ContentLength = offset + n
+	return resp, nil
+}
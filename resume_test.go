@@ -0,0 +1,90 @@
+package remotelist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newPartialDownload leaves the partial download `data` of the list with ETag "v1" next to `local`
+func newPartialDownload(t *testing.T, local, data string) {
+	t.Helper()
+	part := partialFile(local)
+	if err := os.WriteFile(part, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	meta := `{"etag":"\"v1\"","size":-1,"accept_ranges":true}`
+	if err := os.WriteFile(metadataFile(part), []byte(meta), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newRangeServer serves `list` with ETag "v1" and answers range requests with the rest of it
+func newRangeServer(t *testing.T, list string, ranges *[]string) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Accept-Ranges", "bytes")
+		var start int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-", &start); err != nil || start > len(list) {
+			fmt.Fprint(w, list)
+			return
+		}
+		*ranges = append(*ranges, r.Header.Get("Range"))
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/*", start, len(list)-1))
+		w.WriteHeader(http.StatusPartialContent)
+		fmt.Fprint(w, list[start:])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWithResume(t *testing.T) {
+	var ranges []string
+	srv := newRangeServer(t, "a.com\nb.com\n", &ranges)
+	local := filepath.Join(t.TempDir(), "list.txt")
+	newPartialDownload(t, local, "a.com\n")
+
+	rl, err := NewWithOptions(local, srv.URL, WithResume())
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	if got := rl.List(); !slices.Equal(got, []string{"a.com", "b.com"}) || !slices.Equal(ranges, []string{"bytes=6-"}) {
+		t.Errorf("got records %q with range requests %q", got, ranges)
+	}
+	if _, err := os.Stat(partialFile(local)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial download was kept: %v", err)
+	}
+}
+
+func TestWithResumeMaxDownloadSize(t *testing.T) {
+	// The rest of the list never ends, only the limit stops reading it
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Range", "bytes 20-1000000000/*")
+		w.WriteHeader(http.StatusPartialContent)
+		for r.Context().Err() == nil {
+			if _, err := fmt.Fprint(w, "a.com\n"); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+	local := filepath.Join(t.TempDir(), "list.txt")
+
+	// The partial download has reached the limit already, nothing more must be read
+	newPartialDownload(t, local, "aaaaaaaaaaaaaaaaaaaa")
+	_, err := NewWithOptions(local, srv.URL, WithResume(), WithMaxDownloadSize(16), WithDownloadTimeout(2*time.Second))
+	if !errors.Is(err, ErrDownloadTooLarge) {
+		t.Errorf("NewWithOptions: got %v, want ErrDownloadTooLarge", err)
+	}
+	if _, err := os.Stat(partialFile(local)); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial download was kept: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package remotelist
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRetryAfter caps the delay advised by a Retry-After header that is waited for before retrying a download.
+const MaxRetryAfter = 5 * time.Minute
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds
+// or an HTTP date. It returns 0 if the value is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryable reports whether a download that failed with `err` may succeed when it is retried.
+// Errors caused by the content (checksum, size, validation) and client errors other than 429 are permanent.
+func retryable(err error) bool {
+	var errStatus *StatusError
+	if errors.As(err, &errStatus) {
+		return errStatus.StatusCode == http.StatusTooManyRequests || errStatus.StatusCode >= 500
+	}
+	return !errors.Is(err, ErrChecksumMismatch) && !errors.Is(err, ErrDownloadTooLarge) && !errors.Is(err, ErrWriteLocal) && !errors.Is(err, ErrValidation)
+}
+
+// downloadRetry downloads the list from `remote` and retries up to the configured number of times if that fails.
+// Between attempts it waits for the delay advised by a Retry-After header (capped at MaxRetryAfter)
+// or for the backoff, which doubles with every attempt. If all attempts fail, the last error is returned.
+func (rl *RemoteList) downloadRetry(ctx context.Context, remote string, fileInfo os.FileInfo, rewrite bool) error {
+	backoff := rl.retryBackoff
+	for attempt := 0; ; attempt++ {
+		err := rl.downloadFrom(ctx, remote, fileInfo, rewrite)
+		if err == nil || attempt >= rl.retries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay := backoff
+		var errStatus *StatusError
+		if errors.As(err, &errStatus) && errStatus.RetryAfter > 0 {
+			delay = min(errStatus.RetryAfter, MaxRetryAfter)
+		}
+		backoff *= 2
+
+		rl.log(slog.LevelDebug, "retrying list download", "remote", remote, "attempt", attempt+1, "delay", delay, "error", err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package remotelist
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"120", 2 * time.Minute},
+		{" 3 ", 3 * time.Second},
+		{now.Add(30 * time.Second).Format(http.TimeFormat), 30 * time.Second},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0},
+		{"-5", 0},
+		{"soon", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter func() string
+	}{
+		{"seconds", http.StatusTooManyRequests, func() string { return "1" }},
+		// HTTP dates have a resolution of seconds, so this waits between one and two seconds
+		{"HTTP date", http.StatusServiceUnavailable, func() string { return time.Now().Add(2 * time.Second).UTC().Format(http.TimeFormat) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var hits atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if hits.Add(1) == 1 {
+					w.Header().Set("Retry-After", tt.retryAfter())
+					w.WriteHeader(tt.status)
+					return
+				}
+				fmt.Fprint(w, "a.com\n")
+			}))
+			defer srv.Close()
+
+			start := time.Now()
+			rl := newTestList(t, srv.URL, WithRetry(1, time.Millisecond))
+			elapsed := time.Since(start)
+			if hits.Load() != 2 || !rl.Has("a.com") {
+				t.Fatalf("got %d requests and records %q, want 2 and [a.com]", hits.Load(), rl.List())
+			}
+			if elapsed < 900*time.Millisecond || elapsed > 5*time.Second {
+				t.Errorf("retried after %s, want the advised delay", elapsed)
+			}
+		})
+	}
+
+	t.Run("exhausted", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer srv.Close()
+
+		_, err := NewWithOptions(filepath.Join(t.TempDir(), "list.txt"), srv.URL, WithRetry(0, time.Millisecond))
+		var errStatus *StatusError
+		if !errors.As(err, &errStatus) || errStatus.RetryAfter != 7*time.Second {
+			t.Errorf("got %v, want a *StatusError advising 7s", err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package remotelist
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// addedFile returns the path of the sidecar file that stores the records added to `fileLocal` with Add
+func addedFile(fileLocal string) string {
+	return fileLocal + ".added"
+}
+
+// readAdded reads the records persisted by Save from `storage`. A missing file means there are none.
+func readAdded(storage Storage, fileLocal string) (map[string]struct{}, error) {
+	added := map[string]struct{}{}
+	f, err := storage.Open(addedFile(fileLocal))
+	if errors.Is(err, os.ErrNotExist) {
+		return added, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			added[line] = struct{}{}
+		}
+	}
+	return added, scanner.Err()
+}
+
+// Save persists the records added with Add and AddAll, so they survive restarts.
+// They are written to a sidecar file next to the local file (`<fileLocal>.added`) which is never
+// touched by downloads and is merged into the records whenever the list is loaded.
+//
+// The records are written as they are stored, one per line. They are neither passed through the
+// DataFilterFunc nor the DataLineFunc when they are loaded again. Records added with AddWithTTL are
+// not written, whether they have expired or not. Removing records that
+// were downloaded is not persisted, they reappear on the next refresh.
+func (rl *RemoteList) Save() error {
+	if rl.isClosed() {
+		return ErrClosed
+	}
+	rl.mu.RLock()
+	added := []string{}
+	for _, rec := range rl.addedRecords() {
+		if _, ok := rl.expiries[rec]; !ok {
+			added = append(added, rec)
+		}
+	}
+	rl.mu.RUnlock()
+	sort.Strings(added)
+
+	if err := rl.createDir(); err != nil {
+		return err
+	}
+	permissions := rl.localMode()
+
+	err := rl.storage.Write(addedFile(rl.fileLocal), permissions, func(w io.Writer) error {
+		bw := bufio.NewWriter(w)
+		for _, rec := range added {
+			if _, err := bw.WriteString(rec + "\n"); err != nil {
+				return err
+			}
+		}
+		return bw.Flush()
+	})
+	if err != nil {
+		return fmt.Errorf("%w, could not save added records: %w", ErrWriteLocal, err)
+	}
+	return nil
+}
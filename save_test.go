@@ -0,0 +1,65 @@
+package remotelist
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSaveSurvivesRestart(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	local := filepath.Join(t.TempDir(), "list.txt")
+
+	rl, err := NewWithOptions(local, remote.URL)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	rl.Add("manual.com")
+	rl.AddWithTTL("temporary.com", time.Hour)
+	if err := rl.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	rl.Close()
+
+	// The restarted list downloads the list again, which must not drop the saved records
+	remote.set("b.com\n")
+	rl, err = NewWithOptions(local, remote.URL)
+	if err != nil {
+		t.Fatalf("NewWithOptions: %v", err)
+	}
+	defer rl.Close()
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	for v, want := range map[string]bool{"manual.com": true, "b.com": true, "a.com": false, "temporary.com": false} {
+		if got := rl.Has(v); got != want {
+			t.Errorf("Has(%q) = %v, want %v", v, got, want)
+		}
+	}
+}
+
+// failingStorage is a MemoryStorage that fails to write files with the suffix `fail`
+type failingStorage struct {
+	*MemoryStorage
+	fail string
+}
+
+func (fs failingStorage) Write(name string, perm os.FileMode, fn func(w io.Writer) error) error {
+	if strings.HasSuffix(name, fs.fail) {
+		return errors.New("disk full")
+	}
+	return fs.MemoryStorage.Write(name, perm, fn)
+}
+
+func TestSaveWriteError(t *testing.T) {
+	remote := newTestRemote(t, "a.com\n")
+	rl := newTestList(t, remote.URL, WithStorage(failingStorage{NewMemoryStorage(), ".added"}))
+	rl.Add("manual.com")
+	if err := rl.Save(); !errors.Is(err, ErrWriteLocal) {
+		t.Errorf("Save: got %v, want ErrWriteLocal", err)
+	}
+}
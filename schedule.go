@@ -0,0 +1,30 @@
+package remotelist
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// jitterMaxAge returns the maxAge randomized by the configured jitter
+func (rl *RemoteList) jitterMaxAge() time.Duration {
+	if rl.jitter == 0 || rl.maxAge == RefreshNever {
+		return rl.maxAge
+	}
+	return time.Duration(float64(rl.maxAge) * (1 + rl.jitter*(2*rand.Float64()-1)))
+}
+
+// NextRefreshAt returns the time from which on Refresh downloads the list again, i.e. when the local file
+// becomes older than the maxAge (randomized by WithRefreshJitter). If there is no local file, that's now.
+// It returns the zero time if the list is never downloaded again (RefreshNever).
+func (rl *RemoteList) NextRefreshAt() time.Time {
+	fileInfo, err := rl.storage.Stat(rl.fileLocal)
+	if err != nil {
+		return time.Now()
+	}
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	if rl.refreshAge == RefreshNever {
+		return time.Time{}
+	}
+	return fileInfo.ModTime().Add(rl.refreshAge)
+}
@@ -0,0 +1,154 @@
+package remotelist
+
+import (
+	"container/heap"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxHeap is a max-heap of strings, used to keep the lexicographically smallest matches
+type maxHeap []string
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i] > h[j] }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(string)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// SearchN searches for `term` like Search but only returns a page of the sorted results:
+// up to `limit` matches, skipping the first `offset` ones. A `limit` of 0 or less returns all
+// matches after `offset`. Matching is done like DefaultSearchFunc (case-insensitive substring, or
+// case-sensitive with WithCaseSensitive), regardless of the configured SearchFunc.
+//
+// With a `limit`, only the first `offset+limit` matches are kept instead of collecting and sorting
+// all matches, so paging through a broad search on a large list stays cheap in memory.
+func (rl *RemoteList) SearchN(term string, limit, offset int) []string {
+	if offset < 0 {
+		offset = 0
+	}
+	term = rl.fold(rl.query(term))
+
+	rl.rlock()
+	defer rl.mu.RUnlock()
+
+	res := []string{}
+	if limit <= 0 {
+		// Without a limit every match is kept, which doesn't need the heap
+		rl.eachRecord(func(rec string) bool {
+			if strings.Contains(rl.fold(rec), term) {
+				res = append(res, rec)
+			}
+			return true
+		})
+	} else {
+		keep := offset + limit
+		h := &maxHeap{}
+		rl.eachRecord(func(rec string) bool {
+			if !strings.Contains(rl.fold(rec), term) {
+				return true
+			}
+			if h.Len() < keep {
+				heap.Push(h, rec)
+			} else if rec < (*h)[0] {
+				(*h)[0] = rec
+				heap.Fix(h, 0)
+			}
+			return true
+		})
+		res = []string(*h)
+	}
+
+	sort.Strings(res)
+	if offset >= len(res) {
+		return []string{}
+	}
+	return res[offset:]
+}
+
+// SearchRegex returns all records matching the regular expression `pattern` as a sorted slice.
+// If `pattern` is invalid, the compile error is returned.
+func (rl *RemoteList) SearchRegex(pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	res := []string{}
+	rl.eachRecord(func(rec string) bool {
+		if re.MatchString(rec) {
+			res = append(res, rec)
+		}
+		return true
+	})
+	sort.Strings(res)
+	return res, nil
+}
+
+// MatchRegex checks if any record matches the regular expression `pattern`. It stops at the first match.
+// If `pattern` is invalid, the compile error is returned.
+func (rl *RemoteList) MatchRegex(pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	found := false
+	rl.eachRecord(func(rec string) bool {
+		found = re.MatchString(rec)
+		return !found
+	})
+	return found, nil
+}
+
+// MatchGlob returns all records matching the glob `pattern` as a sorted slice.
+// Patterns use path.Match syntax, e.g. `*.example.com` or `ads-??.cdn.*`, and match case-insensitively
+// (unless WithCaseSensitive is used).
+// If `pattern` is malformed, path.ErrBadPattern is returned.
+func (rl *RemoteList) MatchGlob(pattern string) ([]string, error) {
+	pattern = rl.fold(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	res := []string{}
+	rl.eachRecord(func(rec string) bool {
+		if ok, _ := path.Match(pattern, rl.fold(rec)); ok {
+			res = append(res, rec)
+		}
+		return true
+	})
+	sort.Strings(res)
+	return res, nil
+}
+
+// HasGlob checks if any record matches the glob `pattern`. It stops at the first match.
+// Patterns use path.Match syntax and match case-insensitively (unless WithCaseSensitive is used).
+// If `pattern` is malformed, path.ErrBadPattern is returned.
+func (rl *RemoteList) HasGlob(pattern string) (bool, error) {
+	pattern = rl.fold(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return false, err
+	}
+
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	found := false
+	rl.eachRecord(func(rec string) bool {
+		found, _ = path.Match(pattern, rl.fold(rec))
+		return !found
+	})
+	return found, nil
+}
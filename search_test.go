@@ -0,0 +1,75 @@
+package remotelist
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestSearchN(t *testing.T) {
+	rl, err := NewFromStrings([]string{"d.example.com", "a.example.com", "c.example.org", "b.example.com", "other.net"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		term          string
+		limit, offset int
+		want          []string
+	}{
+		{"example", 0, 0, []string{"a.example.com", "b.example.com", "c.example.org", "d.example.com"}},
+		{"EXAMPLE", 2, 0, []string{"a.example.com", "b.example.com"}},
+		{"example", 2, 2, []string{"c.example.org", "d.example.com"}},
+		{"example", 2, 3, []string{"d.example.com"}},
+		{"example", 0, 1, []string{"b.example.com", "c.example.org", "d.example.com"}},
+		{"example", -1, 10, []string{}},
+		{"example", 1, -5, []string{"a.example.com"}},
+		{"missing", 0, 0, []string{}},
+	}
+	for _, tt := range tests {
+		if got := rl.SearchN(tt.term, tt.limit, tt.offset); !slices.Equal(got, tt.want) {
+			t.Errorf("SearchN(%q, %d, %d) = %q, want %q", tt.term, tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestSearchNConcurrentAdd(t *testing.T) {
+	rl, err := NewFromStrings(nil, WithShards(4), WithFastHas())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// An empty list must not break the unlimited search
+	if got := rl.SearchN("a", 0, 0); len(got) != 0 {
+		t.Fatalf("SearchN on an empty list = %q", got)
+	}
+
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 1000 {
+				rl.Add(fmt.Sprintf("host%d-%d.com", i, j))
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	// Records added while searching are either found or not, but never break the search
+	for searching := true; searching; {
+		select {
+		case <-done:
+			searching = false
+		default:
+		}
+		if got := rl.SearchN("host", 0, 0); !slices.IsSorted(got) {
+			t.Fatalf("SearchN returned unsorted matches")
+		}
+	}
+	if got := rl.SearchN("host", 0, 0); len(got) != 4000 {
+		t.Errorf("SearchN returned %d matches, want 4000", len(got))
+	}
+}
@@ -0,0 +1,63 @@
+package remotelist
+
+import (
+	"sort"
+	"unsafe"
+)
+
+// Union returns the records that are in `a` or `b` as a sorted string slice
+func Union(a, b *RemoteList) []string {
+	return combine(a, b, func(inA, inB bool) bool { return inA || inB })
+}
+
+// Intersect returns the records that are in both `a` and `b` as a sorted string slice
+func Intersect(a, b *RemoteList) []string {
+	return combine(a, b, func(inA, inB bool) bool { return inA && inB })
+}
+
+// Difference returns the records that are in `a` but not in `b` as a sorted string slice,
+// e.g. a blocklist minus an allowlist
+func Difference(a, b *RemoteList) []string {
+	return combine(a, b, func(inA, inB bool) bool { return inA && !inB })
+}
+
+// combine returns the sorted records of `a` and `b` for which `keep` returns `true`. Records are compared
+// in the form they are stored in. Both lists are read under their read locks, which are taken in the order
+// of the addresses of the lists, so concurrent calls with swapped arguments can't deadlock.
+func combine(a, b *RemoteList, keep func(inA, inB bool) bool) []string {
+	first, second := a, b
+	if uintptr(unsafe.Pointer(a)) > uintptr(unsafe.Pointer(b)) {
+		first, second = b, a
+	}
+	// Lazy lists are loaded before any lock is held
+	first.lazyInit()
+	second.lazyInit()
+	first.rlock()
+	defer first.mu.RUnlock()
+	if second != first {
+		second.rlock()
+		defer second.mu.RUnlock()
+	}
+
+	inB := map[string]struct{}{}
+	b.eachRecord(func(rec string) bool {
+		inB[rec] = struct{}{}
+		return true
+	})
+	res := []string{}
+	a.eachRecord(func(rec string) bool {
+		_, ok := inB[rec]
+		if keep(true, ok) {
+			res = append(res, rec)
+		}
+		delete(inB, rec)
+		return true
+	})
+	for rec := range inB {
+		if keep(false, true) {
+			res = append(res, rec)
+		}
+	}
+	sort.Strings(res)
+	return res
+}
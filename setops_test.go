@@ -0,0 +1,103 @@
+package remotelist
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSetOperations(t *testing.T) {
+	a, err := NewFromStrings([]string{"a.com", "b.com", "c.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFromStrings([]string{"b.com", "c.com", "d.com"}, WithSortedStorage(), WithFastHas())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{"Union", Union(a, b), []string{"a.com", "b.com", "c.com", "d.com"}},
+		{"Intersect", Intersect(a, b), []string{"b.com", "c.com"}},
+		{"Difference", Difference(a, b), []string{"a.com"}},
+		{"Difference swapped", Difference(b, a), []string{"d.com"}},
+		{"Intersect self", Intersect(a, a), []string{"a.com", "b.com", "c.com"}},
+		{"Difference self", Difference(a, a), []string{}},
+	}
+	for _, tt := range tests {
+		if !slices.Equal(tt.got, tt.want) {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSetOperationsConcurrent(t *testing.T) {
+	a, err := NewFromStrings([]string{"a.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFromStrings([]string{"b.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Swapped arguments and concurrent changes must neither deadlock nor race
+	var wg sync.WaitGroup
+	for i := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				if i%2 == 0 {
+					Union(a, b)
+					a.Add(fmt.Sprintf("a%d-%d.com", i, j))
+				} else {
+					Intersect(b, a)
+					b.Add(fmt.Sprintf("b%d-%d.com", i, j))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n := len(Union(a, b)); n != 402 {
+		t.Errorf("Union has %d records, want 402", n)
+	}
+}
+
+func TestSetOperationsReadLocks(t *testing.T) {
+	a, err := NewFromStrings([]string{"a.com", "b.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := NewFromStrings([]string{"b.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Other readers may hold the read locks of both lists meanwhile
+	a.mu.RLock()
+	b.mu.RLock()
+	done := make(chan []string)
+	go func() { done <- Difference(a, b) }()
+	select {
+	case got := <-done:
+		if !slices.Equal(got, []string{"a.com"}) {
+			t.Errorf("Difference = %q, want [a.com]", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Difference waits for the read locks to be released")
+	}
+	a.mu.RUnlock()
+	b.mu.RUnlock()
+
+	// The records aren't shared with snapshots, so changing them later doesn't copy them
+	if a.published.Load() != nil || b.published.Load() != nil {
+		t.Error("Difference published the records for snapshots")
+	}
+}
@@ -0,0 +1,214 @@
+package remotelist
+
+import (
+	"hash/maphash"
+	"sync"
+)
+
+// recordShard holds the records whose hash maps to it, see WithShards
+type recordShard struct {
+	mu      sync.RWMutex
+	records map[string]struct{}
+	added   map[string]struct{} // Records of this shard added with Add
+}
+
+// newShards distributes `records` and `added` over `n` shards
+func (rl *RemoteList) newShards(n int, records, added map[string]struct{}) []*recordShard {
+	shards := make([]*recordShard, n)
+	for i := range shards {
+		shards[i] = &recordShard{
+			records: make(map[string]struct{}, len(records)/n),
+			added:   map[string]struct{}{},
+		}
+	}
+	for rec := range records {
+		rl.shardIn(shards, rec).records[rec] = struct{}{}
+	}
+	for rec := range added {
+		rl.shardIn(shards, rec).added[rec] = struct{}{}
+	}
+	return shards
+}
+
+// shardIn returns the shard of `shards` that `value` belongs to
+func (rl *RemoteList) shardIn(shards []*recordShard, value string) *recordShard {
+	return shards[maphash.String(rl.shardSeed, value)%uint64(len(shards))]
+}
+
+// mergeShards returns the records of all `shards` in a single map
+func mergeShards(shards []*recordShard) map[string]struct{} {
+	records := map[string]struct{}{}
+	for _, s := range shards {
+		s.mu.RLock()
+		for rec := range s.records {
+			records[rec] = struct{}{}
+		}
+		s.mu.RUnlock()
+	}
+	return records
+}
+
+// The following functions access the records regardless of whether they are sharded or sorted.
+// The caller must hold the read lock, or the write lock to be the only one accessing them.
+
+// hasRecord reports whether `value` is a record
+func (rl *RemoteList) hasRecord(value string) bool {
+	if rl.sortRecords {
+		return sortedHas(rl.sorted, value)
+	}
+	if rl.shards == nil {
+		_, ok := rl.records[value]
+		return ok
+	}
+	s := rl.shardIn(rl.shards, value)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	_, ok := s.records[value]
+	return ok
+}
+
+// addRecord adds `value` to the records and to the records added with Add.
+// It reports whether `value` was not a record before, i.e. if it has to be indexed.
+func (rl *RemoteList) addRecord(value string) bool {
+	if rl.shards != nil {
+		s := rl.shardIn(rl.shards, value)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.added[value] = struct{}{}
+		if _, ok := s.records[value]; ok {
+			return false
+		}
+		s.records[value] = struct{}{}
+		rl.changes.Add(1)
+		return true
+	}
+	rl.unfreeze()
+	rl.added[value] = struct{}{}
+	if rl.sortRecords {
+		return rl.edited(value, true, sortedInsert(&rl.sorted, value))
+	}
+	if _, ok := rl.records[value]; ok {
+		return false
+	}
+	rl.records[value] = struct{}{}
+	return rl.edited(value, true, true)
+}
+
+// removeRecord removes `value` from the records and from the records added with Add.
+// It reports whether `value` was a record, i.e. if it has to be unindexed.
+func (rl *RemoteList) removeRecord(value string) bool {
+	if rl.shards != nil {
+		s := rl.shardIn(rl.shards, value)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.added, value)
+		if _, ok := s.records[value]; !ok {
+			return false
+		}
+		delete(s.records, value)
+		rl.changes.Add(1)
+		return true
+	}
+	rl.unfreeze()
+	delete(rl.added, value)
+	if rl.sortRecords {
+		return rl.edited(value, false, sortedDelete(&rl.sorted, value))
+	}
+	if _, ok := rl.records[value]; !ok {
+		return false
+	}
+	delete(rl.records, value)
+	return rl.edited(value, false, true)
+}
+
+// recordCount returns the number of records
+func (rl *RemoteList) recordCount() int {
+	if rl.sortRecords {
+		return len(rl.sorted)
+	}
+	if rl.shards == nil {
+		return len(rl.records)
+	}
+	n := 0
+	for _, s := range rl.shards {
+		s.mu.RLock()
+		n += len(s.records)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// recordMaps calls `fn` with each map of records (only one unless they are sharded) until it returns `false`.
+// It must not be used for sorted records.
+func (rl *RemoteList) recordMaps(fn func(records map[string]struct{}) bool) {
+	if rl.shards == nil {
+		fn(rl.records)
+		return
+	}
+	for _, s := range rl.shards {
+		s.mu.RLock()
+		ok := fn(s.records)
+		s.mu.RUnlock()
+		if !ok {
+			return
+		}
+	}
+}
+
+// eachRecord calls `fn` for each record in no particular order until it returns `false`
+func (rl *RemoteList) eachRecord(fn func(record string) bool) {
+	if rl.sortRecords {
+		for _, rec := range rl.sorted {
+			if !fn(rec) {
+				return
+			}
+		}
+		return
+	}
+	rl.recordMaps(func(records map[string]struct{}) bool {
+		for rec := range records {
+			if !fn(rec) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// addedRecords returns the records added with Add
+func (rl *RemoteList) addedRecords() []string {
+	res := []string{}
+	if rl.sortRecords || rl.shards == nil {
+		for rec := range rl.added {
+			res = append(res, rec)
+		}
+		return res
+	}
+	for _, s := range rl.shards {
+		s.mu.RLock()
+		for rec := range s.added {
+			res = append(res, rec)
+		}
+		s.mu.RUnlock()
+	}
+	return res
+}
+
+// shared runs `fn` under the read lock if the records are sharded, so only the shard of `value` is locked
+// for writing. It reports whether the caller is done, which it isn't if `value` expires (see AddWithTTL).
+// Once the RemoteList is closed the caller is done without `fn` being run.
+func (rl *RemoteList) shared(fn func(), value string) bool {
+	if rl.shardCount == 0 {
+		return false
+	}
+	rl.mu.RLock()
+	defer rl.mu.RUnlock()
+	if rl.closed {
+		return true
+	}
+	if _, ok := rl.expiries[value]; ok {
+		return false
+	}
+	fn()
+	return true
+}
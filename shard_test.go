@@ -0,0 +1,34 @@
+package remotelist
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+)
+
+// BenchmarkAddParallel measures Add throughput while other goroutines keep calling Has
+func BenchmarkAddParallel(b *testing.B) {
+	for _, shards := range []int{0, 32} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			opts := []Option{WithFastHas()}
+			if shards > 0 {
+				opts = append(opts, WithShards(shards))
+			}
+			rl := newBenchList(b, 100_000, opts...)
+			var n atomic.Int64
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				// Every other goroutine is a reader
+				reader := n.Add(1)%2 == 0
+				for i := 0; pb.Next(); i++ {
+					v := fmt.Sprintf("host%d.example%d.com", n.Add(1), i%1000)
+					if reader {
+						rl.Has(v)
+					} else {
+						rl.Add(v)
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package remotelist
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Snapshot is a read-only, point-in-time copy of the records of a RemoteList. Lookups don't take any locks
+// and always see the same records, even if the RemoteList is refreshed or changed in the meantime.
+type Snapshot struct {
+	rl          *RemoteList  // Only used for its configuration, e.g. the normalization and the HasFunc
+	base        *recordSet   // Records and indexes published by the RemoteList
+	edits       []recordEdit // Changes of the records after base was published, up to this Snapshot
+	overlayOnce sync.Once
+	added       map[string]struct{} // Records added by the edits, nil if there are none
+	removed     map[string]struct{} // Records of base removed by the edits, nil if there are none
+	viewOnce    sync.Once
+	view        *recordSet // Records of base with the edits applied, without indexes
+}
+
+// recordSet holds the records and indexes of a RemoteList that are shared with Snapshots, none of them change
+type recordSet struct {
+	records  map[string]struct{} // Records, nil if they are sorted
+	sorted   []string            // Records if the RemoteList uses WithSortedStorage
+	prefixes *trie
+	suffixes *trie
+	bloom    *bloom
+}
+
+// recordEdit is a change of the records after they have been published for Snapshots
+type recordEdit struct {
+	value string
+	added bool // Whether value has been added or removed
+}
+
+// Snapshot returns a read-only copy of the current records for many consistent lookups, e.g. per request.
+// It only takes the read lock: the records and indexes are published once and shared by the Snapshots that
+// follow. The first Add or Remove after publishing copies them, later changes are logged for the next Snapshots,
+// which apply them on their first lookup. Once the log grows beyond the square root of the number of records,
+// the next Snapshot publishes the current records instead, so neither changes nor Snapshots take O(n).
+// Refreshes replace the records anyway. Only with WithShards the records are copied for each Snapshot.
+// Records added with AddWithTTL stay in the snapshot even if they expire later.
+func (rl *RemoteList) Snapshot() *Snapshot {
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	if rl.shards != nil {
+		return &Snapshot{rl: rl, base: &recordSet{records: mergeShards(rl.shards)}}
+	}
+	// Concurrent Snapshots may publish at the same time, they all use the set that won
+	rl.published.CompareAndSwap(nil, &recordSet{
+		records:  rl.records,
+		sorted:   rl.sorted,
+		prefixes: rl.prefixes,
+		suffixes: rl.suffixes,
+		bloom:    rl.bloom,
+	})
+	return &Snapshot{rl: rl, base: rl.published.Load(), edits: slices.Clip(rl.edits)}
+}
+
+// unfreeze copies the records and rebuilds the indexes before the first change after they have been
+// published for Snapshots, later changes go to the copy. The caller must hold the write lock.
+func (rl *RemoteList) unfreeze() {
+	if rl.copied || rl.published.Load() == nil {
+		return
+	}
+	rl.copied = true
+	records := rl.records
+	if rl.sortRecords {
+		rl.sorted = slices.Clone(rl.sorted)
+		records = make(map[string]struct{}, len(rl.sorted))
+		for _, rec := range rl.sorted {
+			records[rec] = struct{}{}
+		}
+	} else {
+		rl.records = maps.Clone(rl.records)
+		records = rl.records
+	}
+	rl.prefixes = rl.newIndex(rl.indexPrefix, records, false)
+	rl.suffixes = rl.newIndex(rl.indexSuffix, records, true)
+	rl.bloom = rl.newBloomIndex(records)
+}
+
+// edited counts the change of `value` and logs it for the Snapshots if it `changed` the records, it returns `changed`.
+// When there are more changes than the square root of the number of records (at least 64), applying them
+// takes the Snapshots about as long as copying the records, so the records are published again on the next
+// Snapshot instead. The caller must hold the write lock.
+func (rl *RemoteList) edited(value string, added, changed bool) bool {
+	if !changed {
+		return false
+	}
+	rl.changes.Add(1)
+	if rl.published.Load() == nil {
+		return true
+	}
+	rl.edits = append(rl.edits, recordEdit{value: value, added: added})
+	if len(rl.edits) > max(64, int(math.Sqrt(float64(rl.recordCount())))) {
+		rl.unpublish()
+	}
+	return true
+}
+
+// unpublish stops sharing the records with new Snapshots, e.g. because they have been replaced.
+// Existing Snapshots keep what they have. The caller must hold the write lock.
+func (rl *RemoteList) unpublish() {
+	rl.published.Store(nil)
+	rl.edits, rl.copied = nil, false
+}
+
+// overlay returns the records the edits of the Snapshot added to and removed from its base
+func (s *Snapshot) overlay() (added, removed map[string]struct{}) {
+	s.overlayOnce.Do(func() {
+		if len(s.edits) == 0 {
+			return
+		}
+		s.added, s.removed = map[string]struct{}{}, map[string]struct{}{}
+		for _, e := range s.edits {
+			// Each edit changed the records, so it undoes an earlier edit of the same value if there is one
+			undo, apply := s.removed, s.added
+			if !e.added {
+				undo, apply = s.added, s.removed
+			}
+			if _, ok := undo[e.value]; ok {
+				delete(undo, e.value)
+			} else {
+				apply[e.value] = struct{}{}
+			}
+		}
+	})
+	return s.added, s.removed
+}
+
+// records returns the records of the Snapshot with the edits applied. It only copies them if there are edits,
+// which is worth it for the lookups that have to look at all records anyway.
+func (s *Snapshot) records() *recordSet {
+	if len(s.edits) == 0 {
+		return s.base
+	}
+	s.viewOnce.Do(func() {
+		added, removed := s.overlay()
+		records := make(map[string]struct{}, s.base.len()+len(added))
+		maps.Copy(records, added)
+		s.base.each(func(rec string) {
+			if _, ok := removed[rec]; !ok {
+				records[rec] = struct{}{}
+			}
+		})
+		s.view = &recordSet{records: records}
+		if s.rl.sortRecords {
+			s.view = &recordSet{sorted: newSorted(records)}
+		}
+	})
+	return s.view
+}
+
+// indexHasPrefix checks if any record of the Snapshot starts with `prefix` using the index `t` of its base,
+// which has the records reversed if `reversed` is `true`
+func (s *Snapshot) indexHasPrefix(t *trie, prefix string, reversed bool) bool {
+	added, removed := s.overlay()
+	key := func(rec string) string {
+		if rec = s.rl.fold(rec); reversed {
+			rec = reverse(rec)
+		}
+		return rec
+	}
+	for rec := range added {
+		if strings.HasPrefix(key(rec), prefix) {
+			return true
+		}
+	}
+	node := t.find(prefix)
+	if node == nil {
+		return false
+	}
+	n := node.count
+	for rec := range removed {
+		if strings.HasPrefix(key(rec), prefix) {
+			n--
+		}
+	}
+	return n > 0
+}
+
+// Has checks if a value exists in the Snapshot
+func (s *Snapshot) Has(value string) bool {
+	value = s.rl.query(value)
+	added, removed := s.overlay()
+	if s.rl.sortRecords {
+		value = s.rl.fold(value)
+		if _, ok := added[value]; ok {
+			return true
+		}
+		if _, ok := removed[value]; ok {
+			return false
+		}
+		return (s.base.bloom == nil || s.base.bloom.has(value)) && sortedHas(s.base.sorted, value)
+	}
+	if len(added) > 0 && s.rl.fnHas(added, value) {
+		return true
+	}
+	if len(removed) > 0 && s.rl.fnHas(removed, value) {
+		// The record that matches may have been removed, only the remaining records can tell
+		return s.rl.fnHas(s.records().records, value)
+	}
+	if s.base.bloom != nil && !s.base.bloom.has(s.rl.fold(value)) {
+		return false
+	}
+	return s.rl.fnHas(s.base.records, value)
+}
+
+// HasPrefix checks if any record in the Snapshot starts with `prefix`
+func (s *Snapshot) HasPrefix(prefix string) bool {
+	prefix = s.rl.query(prefix)
+	switch {
+	case s.base.prefixes != nil:
+		return s.indexHasPrefix(s.base.prefixes, s.rl.fold(prefix), false)
+	case s.rl.sortRecords:
+		prefix = s.rl.fold(prefix)
+		added, removed := s.overlay()
+		for rec := range added {
+			if strings.HasPrefix(rec, prefix) {
+				return true
+			}
+		}
+		// At most one record per removed one has to be skipped
+		sorted := s.base.sorted
+		for i := sort.SearchStrings(sorted, prefix); i < len(sorted) && strings.HasPrefix(sorted[i], prefix); i++ {
+			if _, ok := removed[sorted[i]]; !ok {
+				return true
+			}
+		}
+		return false
+	}
+	return s.rl.fnHasPrefix(s.records().records, prefix)
+}
+
+// HasSuffix checks if any record in the Snapshot ends with `suffix`
+func (s *Snapshot) HasSuffix(suffix string) bool {
+	suffix = s.rl.query(suffix)
+	switch {
+	case s.base.suffixes != nil:
+		return s.indexHasPrefix(s.base.suffixes, reverse(s.rl.fold(suffix)), true)
+	case s.rl.sortRecords:
+		suffix = s.rl.fold(suffix)
+		return slices.ContainsFunc(s.records().sorted, func(rec string) bool {
+			return strings.HasSuffix(s.rl.fold(rec), suffix)
+		})
+	}
+	return s.rl.fnHasSuffix(s.records().records, suffix)
+}
+
+// Search searches for a value in the Snapshot and returns matching results
+func (s *Snapshot) Search(value string) []string {
+	value = s.rl.query(value)
+	if !s.rl.sortRecords {
+		return s.rl.fnSearch(s.records().records, value)
+	}
+	value = s.rl.fold(value)
+	res := []string{}
+	for _, rec := range s.records().sorted {
+		if strings.Contains(s.rl.fold(rec), value) {
+			res = append(res, rec)
+		}
+	}
+	return res
+}
+
+// Len returns the number of records in the Snapshot
+func (s *Snapshot) Len() int {
+	added, removed := s.overlay()
+	return s.base.len() + len(added) - len(removed)
+}
+
+// List returns the records of the Snapshot as a sorted string slice
+func (s *Snapshot) List() []string {
+	set := s.records()
+	if s.rl.sortRecords {
+		return slices.Clone(set.sorted)
+	}
+	res := make([]string, 0, len(set.records))
+	for rec := range set.records {
+		res = append(res, rec)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// len returns the number of records in the set
+func (set *recordSet) len() int {
+	if set.records == nil {
+		return len(set.sorted)
+	}
+	return len(set.records)
+}
+
+// each calls `fn` for each record of the set in no particular order
+func (set *recordSet) each(fn func(rec string)) {
+	for _, rec := range set.sorted {
+		fn(rec)
+	}
+	for rec := range set.records {
+		fn(rec)
+	}
+}
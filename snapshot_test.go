@@ -0,0 +1,139 @@
+package remotelist
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"indexed", []Option{WithPrefixIndex(), WithSuffixIndex(), WithBloomFilter(0.01)}},
+		{"sorted", []Option{WithSortedStorage(), WithFastHas()}},
+		{"sorted and indexed", []Option{WithSortedStorage(), WithCaseSensitive(), WithPrefixIndex(), WithSuffixIndex()}},
+		{"sharded", []Option{WithShards(4), WithFastHas()}},
+		{"custom HasFunc", []Option{WithHasFunc(func(records map[string]struct{}, value string) bool {
+			// Matches records by their first label
+			label, _, _ := strings.Cut(value, ".")
+			for rec := range records {
+				if strings.HasPrefix(rec, label+".") {
+					return true
+				}
+			}
+			return false
+		})}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromStrings([]string{"a.com", "b.com", "c.org"}, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer rl.Close()
+
+			first := rl.Snapshot()
+			rl.Add("d.net")
+			rl.Remove("b.com")
+			second := rl.Snapshot()
+			rl.Remove("d.net")
+			rl.Add("b.com")
+			rl.Remove("c.org")
+			third := rl.Snapshot()
+
+			checks := []struct {
+				s    *Snapshot
+				want []string
+			}{
+				{first, []string{"a.com", "b.com", "c.org"}},
+				{second, []string{"a.com", "c.org", "d.net"}},
+				{third, []string{"a.com", "b.com"}},
+			}
+			for i, c := range checks {
+				if got := c.s.List(); !slices.Equal(got, c.want) || c.s.Len() != len(c.want) {
+					t.Errorf("snapshot %d: List() = %q, Len() = %d, want %q", i, got, c.s.Len(), c.want)
+				}
+				for _, rec := range []string{"a.com", "b.com", "c.org", "d.net"} {
+					want := slices.Contains(c.want, rec)
+					if got := c.s.Has(rec); got != want {
+						t.Errorf("snapshot %d: Has(%q) = %v, want %v", i, rec, got, want)
+					}
+					if got := c.s.HasPrefix(rec[:1]); got != want {
+						t.Errorf("snapshot %d: HasPrefix(%q) = %v, want %v", i, rec[:1], got, want)
+					}
+				}
+				if got, want := c.s.HasSuffix(".org"), slices.Contains(c.want, "c.org"); got != want {
+					t.Errorf("snapshot %d: HasSuffix(.org) = %v, want %v", i, got, want)
+				}
+				if got := c.s.Search(".c"); !slices.Equal(got, slices.DeleteFunc(slices.Clone(c.want), func(rec string) bool {
+					return !strings.Contains(rec, ".c")
+				})) {
+					t.Errorf("snapshot %d: Search(.c) = %q", i, got)
+				}
+			}
+
+			// Refreshes replace the records, the snapshots keep theirs
+			rl.Clear()
+			if rl.Snapshot().Len() != 0 || first.Len() != 3 {
+				t.Errorf("got %d records after clearing, first snapshot has %d", rl.Snapshot().Len(), first.Len())
+			}
+		})
+	}
+}
+
+func TestSnapshotReadLock(t *testing.T) {
+	rl, err := NewFromStrings([]string{"a.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+
+	// Snapshots can be taken while other readers hold the read lock
+	rl.mu.RLock()
+	done := make(chan *Snapshot)
+	go func() { done <- rl.Snapshot() }()
+	select {
+	case s := <-done:
+		if !s.Has("a.com") {
+			t.Errorf("List() = %q, want [a.com]", s.List())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Snapshot waits for the read lock to be released")
+	}
+	rl.mu.RUnlock()
+}
+
+func TestSnapshotCopies(t *testing.T) {
+	rl, err := NewFromStrings(testDomains(10000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rl.Close()
+
+	// A snapshot per change copies the records only once per square root of them
+	copies := 0
+	for i := range 1000 {
+		s := rl.Snapshot()
+		if !s.Has("host0.example0.com") {
+			t.Fatalf("snapshot %d misses host0.example0.com", i)
+		}
+		rl.Add(fmt.Sprintf("new%d.example.com", i))
+		if !rl.copied {
+			continue
+		}
+		if len(rl.edits) == 1 {
+			copies++
+		}
+	}
+	if copies > 20 {
+		t.Errorf("records were copied %d times for 1000 changes", copies)
+	}
+	if n := rl.Snapshot().Len(); n != 11000 {
+		t.Errorf("Len() = %d, want 11000", n)
+	}
+}
@@ -0,0 +1,49 @@
+package remotelist
+
+import (
+	"slices"
+	"sort"
+	"strings"
+)
+
+// newSorted returns the `records` as a sorted slice
+func newSorted(records map[string]struct{}) []string {
+	sorted := make([]string, 0, len(records))
+	for rec := range records {
+		sorted = append(sorted, rec)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// sortedHas reports whether `value` is in the sorted slice
+func sortedHas(sorted []string, value string) bool {
+	i := sort.SearchStrings(sorted, value)
+	return i < len(sorted) && sorted[i] == value
+}
+
+// sortedHasPrefix reports whether any string in the sorted slice starts with `prefix`
+func sortedHasPrefix(sorted []string, prefix string) bool {
+	i := sort.SearchStrings(sorted, prefix)
+	return i < len(sorted) && strings.HasPrefix(sorted[i], prefix)
+}
+
+// sortedInsert inserts `value` into the sorted slice if it is missing and reports whether it was
+func sortedInsert(sorted *[]string, value string) bool {
+	i := sort.SearchStrings(*sorted, value)
+	if i < len(*sorted) && (*sorted)[i] == value {
+		return false
+	}
+	*sorted = slices.Insert(*sorted, i, value)
+	return true
+}
+
+// sortedDelete removes `value` from the sorted slice and reports whether it was in it
+func sortedDelete(sorted *[]string, value string) bool {
+	i := sort.SearchStrings(*sorted, value)
+	if i == len(*sorted) || (*sorted)[i] != value {
+		return false
+	}
+	*sorted = slices.Delete(*sorted, i, i+1)
+	return true
+}
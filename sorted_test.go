@@ -0,0 +1,38 @@
+package remotelist
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// BenchmarkSortedStorage compares the memory and lookup cost of sorted records with the default map
+func BenchmarkSortedStorage(b *testing.B) {
+	domains := testDomains(1_000_000)
+	for _, sorted := range []bool{false, true} {
+		b.Run(fmt.Sprintf("sorted=%v", sorted), func(b *testing.B) {
+			opts := []Option{WithFastHas()}
+			if sorted {
+				opts = append(opts, WithSortedStorage())
+			}
+			var before, after runtime.MemStats
+			runtime.GC()
+			runtime.ReadMemStats(&before)
+			rl, err := NewFromStrings(domains, opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			runtime.GC()
+			runtime.ReadMemStats(&after)
+			heap := int64(after.HeapAlloc) - int64(before.HeapAlloc)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rl.Has(domains[i%len(domains)])
+				rl.HasPrefix("host99999")
+			}
+			b.ReportMetric(float64(heap), "heap-bytes")
+			runtime.KeepAlive(rl)
+		})
+	}
+}
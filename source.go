@@ -0,0 +1,61 @@
+package remotelist
+
+import "sort"
+
+// Source is the origin of a record
+type Source int
+
+const (
+	SourceRemote   Source = iota // The record was downloaded from the remote location
+	SourceOverride               // The record was read from a local override file (see WithLocalOverrides)
+	SourceManual                 // The record was added with Add, AddAll or AddWithTTL or persisted with Save
+)
+
+// String returns the name of the Source
+func (s Source) String() string {
+	switch s {
+	case SourceRemote:
+		return "remote"
+	case SourceOverride:
+		return "override"
+	case SourceManual:
+		return "manual"
+	}
+	return "unknown"
+}
+
+// ListBySource returns the records that originate from `source` as a sorted string slice.
+// Records that have been added with Add although they were also downloaded count as SourceManual,
+// records that are both downloaded and in an override file count as SourceOverride.
+func (rl *RemoteList) ListBySource(source Source) []string {
+	rl.rlock()
+	defer rl.mu.RUnlock()
+	sourceOf := rl.sources()
+	res := []string{}
+	rl.eachRecord(func(rec string) bool {
+		if sourceOf(rec) == source {
+			res = append(res, rec)
+		}
+		return true
+	})
+	sort.Strings(res)
+	return res
+}
+
+// sources returns a function that returns the Source of a record. The caller must hold the read lock
+// while using it. It doesn't lock the shards, so it can be used while iterating the records.
+func (rl *RemoteList) sources() func(record string) Source {
+	added := map[string]struct{}{}
+	for _, rec := range rl.addedRecords() {
+		added[rec] = struct{}{}
+	}
+	return func(record string) Source {
+		if _, ok := added[record]; ok {
+			return SourceManual
+		}
+		if _, ok := rl.overridden[record]; ok {
+			return SourceOverride
+		}
+		return SourceRemote
+	}
+}
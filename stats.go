@@ -0,0 +1,56 @@
+package remotelist
+
+import (
+	"time"
+)
+
+// Stats describes the state of a RemoteList
+type Stats struct {
+	RecordCount          int           // Number of records
+	LocalPath            string        // Filepath of the local file, empty if the list is kept in memory only
+	RemoteURL            string        // Remote location of the list
+	Source               string        // Remote location (RemoteURL or a mirror) the list was last downloaded from
+	LastDownload         time.Time     // Time of the last successful download, zero if there was none
+	LastDownloadDuration time.Duration // Duration of the last successful download
+	FileSizeBytes        int64         // Size of the local file, -1 if it doesn't exist or the list is kept in memory only
+	InMemory             bool          // Whether the list is kept in memory only, without a local file
+	RejectedLines        int           // Number of lines the DataLineFunc rejected during the last load
+	MalformedEntries     int           // Number of malformed entries (e.g. CSV rows) skipped during the last load
+	RejectedDownloads    int           // Number of downloads rejected by a validator (see WithValidator), the previous list was kept
+	Generation           int           // Backup generation of the local file that is loaded, 0 if it is the latest download (see Rollback)
+	LastRefresh          time.Time     // Time of the last refresh that loaded up-to-date records, zero if there was none
+	Stale                bool          // Whether the records are based on an outdated local file (see IsStale)
+	LastError            error         // Error of the most recent refresh, nil if it succeeded
+}
+
+// Stats returns the current state of the RemoteList. It doesn't load a list created with WithLazyInit,
+// which has no records until it is used.
+func (rl *RemoteList) Stats() Stats {
+	size, path := int64(-1), ""
+	if !rl.memoryOnly {
+		path = rl.fileLocal
+		if fileInfo, err := rl.storage.Stat(rl.fileLocal); err == nil {
+			size = fileInfo.Size()
+		}
+	}
+
+	rl.rlockSwept()
+	defer rl.mu.RUnlock()
+	return Stats{
+		RecordCount:          rl.recordCount(),
+		LocalPath:            path,
+		RemoteURL:            rl.fileRemote,
+		Source:               rl.source,
+		LastDownload:         rl.lastDownload,
+		LastDownloadDuration: rl.lastDuration,
+		FileSizeBytes:        size,
+		RejectedLines:        rl.rejected,
+		MalformedEntries:     rl.malformed,
+		InMemory:             rl.memoryOnly,
+		RejectedDownloads:    rl.invalid,
+		Generation:           rl.generation,
+		LastRefresh:          rl.lastRefresh,
+		Stale:                rl.isStale(),
+		LastError:            rl.lastErr,
+	}
+}
@@ -0,0 +1,162 @@
+package remotelist
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sync"
+	"time"
+)
+
+// A `Storage` stores the local file of a RemoteList and its sidecar files.
+//
+// Implement this interface and set it with WithStorage to keep lists somewhere other than the local file system.
+// The names passed to a Storage are the local path of the list and that path with a suffix. If a Storage also has
+// a `Rename(from, to string) error` method that replaces `to`, backups (see WithBackups) are renamed instead of copied.
+type Storage interface {
+	// Open opens the file `name` for reading. It fails with an error matching os.ErrNotExist if there is none.
+	Open(name string) (io.ReadCloser, error)
+
+	// Write replaces the file `name` with the content written by `fn`. The replacement must be atomic:
+	// if `fn` fails, the file keeps its previous content.
+	Write(name string, perm os.FileMode, fn func(w io.Writer) error) error
+
+	// Stat returns information about the file `name`. It fails with an error matching os.ErrNotExist if there is none.
+	Stat(name string) (os.FileInfo, error)
+
+	// Touch sets the modification time of the file `name` to `t`.
+	Touch(name string, t time.Time) error
+
+	// Remove removes the file `name`. Removing a file that doesn't exist is not an error.
+	Remove(name string) error
+}
+
+// FileStorage stores lists in the local file system. It is used unless another Storage is set with WithStorage.
+type FileStorage struct{}
+
+func (FileStorage) Open(name string) (io.ReadCloser, error) {
+	return os.Open(name)
+}
+
+// Write writes to a temporary file next to `name` which replaces `name` once it has been flushed to disk.
+func (FileStorage) Write(name string, perm os.FileMode, fn func(w io.Writer) error) error {
+	return writeFile(name, perm, fn)
+}
+
+// Rename renames the file `from` to `to`, replacing `to` if it exists.
+func (FileStorage) Rename(from, to string) error {
+	return os.Rename(from, to)
+}
+
+func (FileStorage) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (FileStorage) Touch(name string, t time.Time) error {
+	return os.Chtimes(name, t, t)
+}
+
+func (FileStorage) Remove(name string) error {
+	err := os.Remove(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// MemoryStorage stores lists in memory, e.g. for read-only file systems or tests. It is safe for concurrent use.
+type MemoryStorage struct {
+	mu    *sync.RWMutex
+	files map[string]*memoryFile
+}
+
+// memoryFile is a file stored by a MemoryStorage, its content is never modified once stored
+type memoryFile struct {
+	name    string
+	data    []byte
+	mode    os.FileMode
+	modTime time.Time
+}
+
+func (f *memoryFile) Name() string       { return path.Base(f.name) }
+func (f *memoryFile) Size() int64        { return int64(len(f.data)) }
+func (f *memoryFile) Mode() os.FileMode  { return f.mode }
+func (f *memoryFile) ModTime() time.Time { return f.modTime }
+func (f *memoryFile) IsDir() bool        { return false }
+func (f *memoryFile) Sys() any           { return nil }
+
+// NewMemoryStorage creates an empty MemoryStorage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		mu:    &sync.RWMutex{},
+		files: map[string]*memoryFile{},
+	}
+}
+
+// file returns the file `name` or an error matching os.ErrNotExist if there is none
+func (ms *MemoryStorage) file(op, name string) (*memoryFile, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	f, ok := ms.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return f, nil
+}
+
+func (ms *MemoryStorage) Open(name string) (io.ReadCloser, error) {
+	f, err := ms.file("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(f.data)), nil
+}
+
+// Write buffers the content in memory and only stores it once `fn` succeeded.
+func (ms *MemoryStorage) Write(name string, perm os.FileMode, fn func(w io.Writer) error) error {
+	buf := &bytes.Buffer{}
+	if err := fn(buf); err != nil {
+		return err
+	}
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.files[name] = &memoryFile{name: name, data: buf.Bytes(), mode: perm, modTime: time.Now()}
+	return nil
+}
+
+// Rename renames the file `from` to `to`, replacing `to` if it exists.
+func (ms *MemoryStorage) Rename(from, to string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	f, ok := ms.files[from]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: from, Err: fs.ErrNotExist}
+	}
+	delete(ms.files, from)
+	ms.files[to] = &memoryFile{name: to, data: f.data, mode: f.mode, modTime: f.modTime}
+	return nil
+}
+
+func (ms *MemoryStorage) Stat(name string) (os.FileInfo, error) {
+	return ms.file("stat", name)
+}
+
+func (ms *MemoryStorage) Touch(name string, t time.Time) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	f, ok := ms.files[name]
+	if !ok {
+		return &fs.PathError{Op: "touch", Path: name, Err: fs.ErrNotExist}
+	}
+	ms.files[name] = &memoryFile{name: name, data: f.data, mode: f.mode, modTime: t}
+	return nil
+}
+
+func (ms *MemoryStorage) Remove(name string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.files, name)
+	return nil
+}
@@ -0,0 +1,46 @@
+package remotelist
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+)
+
+// configureClient derives the HTTP client from the configured one if TLS or proxy settings have been given.
+// The configured client is never modified: the derived client keeps its timeout, redirect policy and cookie jar
+// and uses a clone of its transport with the settings applied. That requires the transport to be an
+// *http.Transport (or unset, which means http.DefaultTransport).
+func (rl *RemoteList) configureClient() error {
+	if rl.tlsConfig == nil && rl.rootCAs == nil && rl.proxy == nil {
+		return nil
+	}
+
+	var transport *http.Transport
+	switch t := rl.client.Transport.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return fmt.Errorf("can't apply transport settings to a client with a transport of type %T", t)
+	}
+
+	if rl.tlsConfig != nil {
+		transport.TLSClientConfig = rl.tlsConfig.Clone()
+	}
+	if rl.rootCAs != nil {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.RootCAs = rl.rootCAs
+	}
+
+	if rl.proxy != nil {
+		transport.Proxy = http.ProxyURL(rl.proxy)
+	}
+
+	client := *rl.client
+	client.Transport = transport
+	rl.client = &client
+	return nil
+}
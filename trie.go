@@ -0,0 +1,103 @@
+package remotelist
+
+// trie is a byte-wise prefix tree over strings. It is used to index records
+// so that prefix (and, on reversed strings, suffix) queries don't need to scan all records.
+type trie struct {
+	root *trieNode
+}
+
+// trieNode is a node of a trie
+type trieNode struct {
+	children map[byte]*trieNode
+	count    int // number of strings in the subtree of this node
+	ends     int // number of strings ending at this node
+}
+
+// newTrie creates an empty trie
+func newTrie() *trie {
+	return &trie{root: &trieNode{}}
+}
+
+// insert adds `s` to the trie. Inserting the same string twice requires removing it twice.
+func (t *trie) insert(s string) {
+	node := t.root
+	node.count++
+	for i := 0; i < len(s); i++ {
+		child, ok := node.children[s[i]]
+		if !ok {
+			if node.children == nil {
+				node.children = map[byte]*trieNode{}
+			}
+			child = &trieNode{}
+			node.children[s[i]] = child
+		}
+		child.count++
+		node = child
+	}
+	node.ends++
+}
+
+// remove removes `s` from the trie, pruning nodes that no longer hold any strings.
+// Removing a string that is not in the trie is a no-op.
+func (t *trie) remove(s string) {
+	if !t.has(s) {
+		return
+	}
+	node := t.root
+	node.count--
+	for i := 0; i < len(s); i++ {
+		child := node.children[s[i]]
+		child.count--
+		if child.count == 0 {
+			delete(node.children, s[i])
+			return
+		}
+		node = child
+	}
+	node.ends--
+}
+
+// find returns the node for `prefix` or `nil` if no string starts with `prefix`
+func (t *trie) find(prefix string) *trieNode {
+	node := t.root
+	for i := 0; i < len(prefix) && node != nil; i++ {
+		node = node.children[prefix[i]]
+	}
+	return node
+}
+
+// has checks if the trie contains `s`
+func (t *trie) has(s string) bool {
+	node := t.find(s)
+	return node != nil && node.ends > 0
+}
+
+// hasPrefix checks if any string in the trie starts with `prefix`
+func (t *trie) hasPrefix(prefix string) bool {
+	node := t.find(prefix)
+	return node != nil && node.count > 0
+}
+
+// longestPrefix returns the length of the longest string in the trie that is a prefix of `s`
+func (t *trie) longestPrefix(s string) (length int, ok bool) {
+	node := t.root
+	for i := 0; node != nil; i++ {
+		if node.ends > 0 {
+			length, ok = i, true
+		}
+		if i == len(s) {
+			break
+		}
+		node = node.children[s[i]]
+	}
+	return length, ok
+}
+
+// reverse returns `s` with its bytes in reverse order
+func reverse(s string) string {
+	b := make([]byte, len(s))
+	for i := 0; i < len(s); i++ {
+		b[len(s)-1-i] = s[i]
+	}
+	return string(b)
+}
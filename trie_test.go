@@ -0,0 +1,89 @@
+package remotelist
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPrefixIndex(t *testing.T) {
+	remote := newTestRemote(t, "/api/users\n/static/app.js\n")
+	rl := newTestList(t, remote.URL, WithPrefixIndex())
+
+	check := func(want map[string]bool) {
+		t.Helper()
+		for prefix, ok := range want {
+			if got := rl.HasPrefix(prefix); got != ok {
+				t.Errorf("HasPrefix(%q) = %v, want %v", prefix, got, ok)
+			}
+		}
+	}
+	check(map[string]bool{"/API/": true, "/static/": true, "/admin": false, "/api/users/1": false})
+
+	rl.Add("/admin/login")
+	rl.Remove("/static/app.js")
+	check(map[string]bool{"/admin": true, "/static/": false})
+
+	// A reload rebuilds the index from the new records and the ones added with Add
+	remote.set("/health\n")
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	check(map[string]bool{"/health": true, "/api/": false, "/admin": true})
+}
+
+func BenchmarkHasPrefix(b *testing.B) {
+	for _, index := range []bool{false, true} {
+		b.Run(fmt.Sprintf("index=%v", index), func(b *testing.B) {
+			var opts []Option
+			if index {
+				opts = append(opts, WithPrefixIndex())
+			}
+			rl := newBenchList(b, 100_000, opts...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rl.HasPrefix("host99999.")
+			}
+		})
+	}
+}
+
+func TestSuffixIndex(t *testing.T) {
+	remote := newTestRemote(t, "example.com\nads.example.org\n")
+	rl := newTestList(t, remote.URL, WithSuffixIndex())
+
+	check := func(want map[string]bool) {
+		t.Helper()
+		for suffix, ok := range want {
+			if got := rl.HasSuffix(suffix); got != ok {
+				t.Errorf("HasSuffix(%q) = %v, want %v", suffix, got, ok)
+			}
+		}
+	}
+	check(map[string]bool{".COM": true, "example.org": true, ".net": false, "www.example.com": false})
+
+	rl.Add("tracker.net")
+	rl.Remove("ads.example.org")
+	check(map[string]bool{".net": true, ".org": false})
+
+	remote.set("example.io\n")
+	if err := rl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	check(map[string]bool{".io": true, ".com": false, ".net": true})
+}
+
+func BenchmarkHasSuffix(b *testing.B) {
+	for _, index := range []bool{false, true} {
+		b.Run(fmt.Sprintf("index=%v", index), func(b *testing.B) {
+			var opts []Option
+			if index {
+				opts = append(opts, WithSuffixIndex())
+			}
+			rl := newBenchList(b, 1_000_000, opts...)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rl.HasSuffix(".example1000.com")
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package remotelist
+
+import "time"
+
+// AddWithTTL adds a value to the RemoteList that expires after `ttl`, e.g. to block an address temporarily.
+// Expired records are never returned by lookups and searches and don't count towards Len. They are removed
+// lazily by the next lookup and by a timer that fires when the next record expires.
+//
+// Like records added with Add, these records are kept across refreshes until they expire, but they are
+// not written by Save, so they don't survive restarts. Adding a value again replaces its expiry. Values
+// that are already in the RemoteList without an expiry are left as they are. A `ttl` <= 0 adds nothing.
+func (rl *RemoteList) AddWithTTL(value string, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	expiry := time.Now().Add(ttl)
+
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		return
+	}
+	value = rl.normalize(value)
+	if _, ok := rl.expiries[value]; !ok && rl.hasRecord(value) {
+		return
+	}
+	if rl.expiries == nil {
+		rl.expiries = map[string]time.Time{}
+	}
+	rl.expiries[value] = expiry
+	if rl.addRecord(value) {
+		rl.index(value)
+	}
+	if rl.nextExpiry.IsZero() || expiry.Before(rl.nextExpiry) {
+		rl.nextExpiry = expiry
+		rl.scheduleExpiry()
+	}
+}
+
+// rlock acquires the read lock after removing the records that have expired,
+// a list created with WithLazyInit is loaded first
+func (rl *RemoteList) rlock() {
+	rl.lazyInit()
+	rl.rlockSwept()
+}
+
+// rlockSwept is like rlock but doesn't load a list created with WithLazyInit
+func (rl *RemoteList) rlockSwept() {
+	rl.mu.RLock()
+	for len(rl.expiries) > 0 && !time.Now().Before(rl.nextExpiry) {
+		rl.mu.RUnlock()
+		rl.mu.Lock()
+		rl.sweep(time.Now())
+		rl.mu.Unlock()
+		rl.mu.RLock()
+	}
+}
+
+// expire is called by the expiry timer, it removes the records that have expired and schedules the next run
+func (rl *RemoteList) expire() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.sweep(time.Now())
+	if len(rl.expiries) > 0 {
+		rl.scheduleExpiry()
+	}
+}
+
+// scheduleExpiry (re)starts the expiry timer for the next expiry unless the RemoteList has been closed,
+// the caller must hold the write lock
+func (rl *RemoteList) scheduleExpiry() {
+	if rl.closed {
+		return
+	}
+	d := time.Until(rl.nextExpiry)
+	if rl.expiryTimer == nil {
+		rl.expiryTimer = time.AfterFunc(d, rl.expire)
+		return
+	}
+	rl.expiryTimer.Reset(d)
+}
+
+// sweep removes the records that have expired at `now` and determines the next expiry,
+// the caller must hold the write lock
+func (rl *RemoteList) sweep(now time.Time) {
+	rl.nextExpiry = time.Time{}
+	for rec, expiry := range rl.expiries {
+		if now.Before(expiry) {
+			if rl.nextExpiry.IsZero() || expiry.Before(rl.nextExpiry) {
+				rl.nextExpiry = expiry
+			}
+			continue
+		}
+		delete(rl.expiries, rec)
+		if rl.removeRecord(rec) {
+			rl.unindex(rec)
+		}
+	}
+}
+
+// keepAdded adds the records that had been added with Add and AddWithTTL before the swap (`kept`) to the
+// swapped-in records and to `records`, the map the OnChangeFunc diff is computed from. Records with an expiry
+// that have expired or that are in the swapped-in records already, e.g. because they are part of the
+// downloaded list, are dropped together with their expiry. The caller must hold the write lock.
+func (rl *RemoteList) keepAdded(kept []string, records map[string]struct{}) {
+	now := time.Now()
+	for _, rec := range kept {
+		if expiry, ok := rl.expiries[rec]; ok && (!now.Before(expiry) || rl.hasRecord(rec)) {
+			delete(rl.expiries, rec)
+			continue
+		}
+		if rl.addRecord(rec) {
+			rl.index(rec)
+		}
+		records[rec] = struct{}{}
+	}
+}
@@ -0,0 +1,166 @@
+package remotelist
+
+import (
+	"context"
+	"iter"
+	"sync"
+	"time"
+)
+
+// TypedList is like a RemoteList, but each record is parsed into a value of type T, e.g. a netip.Addr or a struct.
+// It uses the same download and refresh machinery as RemoteList.
+type TypedList[T comparable] struct {
+	list    *RemoteList
+	fnParse func(line string) (T, bool) // Function for parsing each record into a T
+	mu      *sync.RWMutex
+	records map[T]struct{} // records stores the parsed data from the list file
+}
+
+// NewTyped creates a new TypedList instance that downloads `fileRemote` to `fileLocal` and parses each line with
+// `parse`. Lines are run through the DataLineFunc first (which drops comments and empty lines by default),
+// `parse` receives the remaining records and can exclude them (`ok = false`), e.g. if they are malformed.
+// The options are the same as for NewWithOptions; options that configure the records of a RemoteList
+// (e.g. the HasFunc or the indexes) have no effect.
+func NewTyped[T comparable](fileLocal, fileRemote string, parse func(line string) (T, bool), opts ...Option) (*TypedList[T], error) {
+	return NewTypedContext(context.Background(), fileLocal, fileRemote, parse, opts...)
+}
+
+// NewTypedContext is like NewTyped but aborts the initial download when `ctx` is canceled
+func NewTypedContext[T comparable](ctx context.Context, fileLocal, fileRemote string, parse func(line string) (T, bool), opts ...Option) (*TypedList[T], error) {
+	rl, err := newRemoteList(fileLocal, fileRemote, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	tl := &TypedList[T]{
+		list:    rl,
+		fnParse: parse,
+		mu:      &sync.RWMutex{},
+		records: map[T]struct{}{},
+	}
+	rl.store = tl
+
+	if err := rl.start(ctx); err != nil {
+		return nil, err
+	}
+	return tl, nil
+}
+
+// parse implements recordStore
+func (tl *TypedList[T]) parse(lines iter.Seq[string]) (commit func(), records, rejected int) {
+	parsed := map[T]struct{}{}
+	for line := range lines {
+		line, ok := tl.list.fnDataLine(line)
+		if !ok {
+			rejected++
+			continue
+		}
+		rec, ok := tl.fnParse(line)
+		if !ok {
+			rejected++
+			continue
+		}
+		parsed[rec] = struct{}{}
+	}
+	return func() {
+		tl.mu.Lock()
+		tl.records = parsed
+		tl.mu.Unlock()
+	}, len(parsed), rejected
+}
+
+// Has checks if `value` exists in the TypedList
+func (tl *TypedList[T]) Has(value T) bool {
+	tl.list.lazyInit()
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	_, ok := tl.records[value]
+	return ok
+}
+
+// Len returns the number of records in the TypedList
+func (tl *TypedList[T]) Len() int {
+	tl.list.lazyInit()
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	return len(tl.records)
+}
+
+// List returns the records of the TypedList in no particular order
+func (tl *TypedList[T]) List() []T {
+	tl.list.lazyInit()
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	res := make([]T, 0, len(tl.records))
+	for rec := range tl.records {
+		res = append(res, rec)
+	}
+	return res
+}
+
+// Search returns the records for which `match` returns `true` in no particular order
+func (tl *TypedList[T]) Search(match func(record T) bool) []T {
+	tl.list.lazyInit()
+	tl.mu.RLock()
+	defer tl.mu.RUnlock()
+	res := []T{}
+	for rec := range tl.records {
+		if match(rec) {
+			res = append(res, rec)
+		}
+	}
+	return res
+}
+
+// Refresh downloads the list again if the local file is older than maxAge (or always if `force` is `true`)
+// and replaces the records with the freshly parsed ones. See RemoteList.Refresh.
+func (tl *TypedList[T]) Refresh(force bool) error {
+	return tl.list.Refresh(force)
+}
+
+// RefreshContext is like Refresh but aborts the download when `ctx` is canceled.
+func (tl *TypedList[T]) RefreshContext(ctx context.Context, force bool) error {
+	return tl.list.RefreshContext(ctx, force)
+}
+
+// EnsureLoaded loads the list unless it has been loaded already. See RemoteList.EnsureLoaded.
+func (tl *TypedList[T]) EnsureLoaded(ctx context.Context) error {
+	return tl.list.EnsureLoaded(ctx)
+}
+
+// StartAutoRefresh starts a goroutine that calls Refresh every `interval`. See RemoteList.StartAutoRefresh.
+func (tl *TypedList[T]) StartAutoRefresh(interval time.Duration) {
+	tl.list.StartAutoRefresh(interval)
+}
+
+// Stop stops the auto-refresh goroutine (if any) and waits for it to exit.
+func (tl *TypedList[T]) Stop() {
+	tl.list.Stop()
+}
+
+// Close stops all background work and aborts a download that is in progress. See RemoteList.Close.
+func (tl *TypedList[T]) Close() error {
+	return tl.list.Close()
+}
+
+// LastError returns the error of the most recent refresh or `nil` if it succeeded.
+func (tl *TypedList[T]) LastError() error {
+	return tl.list.LastError()
+}
+
+// IsStale returns `true` if the records are based on an outdated local file. See RemoteList.IsStale.
+func (tl *TypedList[T]) IsStale() bool {
+	return tl.list.IsStale()
+}
+
+// LastRefresh returns the time of the last refresh that loaded up-to-date records. See RemoteList.LastRefresh.
+func (tl *TypedList[T]) LastRefresh() time.Time {
+	return tl.list.LastRefresh()
+}
+
+// Stats returns the current state of the TypedList
+func (tl *TypedList[T]) Stats() Stats {
+	stats := tl.list.Stats()
+	stats.RecordCount = tl.Len()
+	return stats
+}
@@ -0,0 +1,45 @@
+package remotelist
+
+import (
+	"net/netip"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTypedList(t *testing.T) {
+	remote := newTestRemote(t, "# blocked addresses\n203.0.113.7\n10.0.0.1\nnot-an-ip\n2001:db8::1\n")
+	tl, err := NewTyped(filepath.Join(t.TempDir(), "list.txt"), remote.URL, func(line string) (netip.Addr, bool) {
+		addr, err := netip.ParseAddr(line)
+		return addr, err == nil
+	})
+	if err != nil {
+		t.Fatalf("NewTyped: %v", err)
+	}
+	defer tl.Close()
+
+	if n := tl.Len(); n != 3 {
+		t.Errorf("Len() = %d, want 3", n)
+	}
+	if !tl.Has(netip.MustParseAddr("203.0.113.7")) || tl.Has(netip.MustParseAddr("203.0.113.8")) {
+		t.Errorf("List() = %v", tl.List())
+	}
+	if got := tl.Search(netip.Addr.IsPrivate); !slices.Equal(got, []netip.Addr{netip.MustParseAddr("10.0.0.1")}) {
+		t.Errorf("Search(IsPrivate) = %v, want [10.0.0.1]", got)
+	}
+	list := tl.List()
+	slices.SortFunc(list, netip.Addr.Compare)
+	want := []netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("203.0.113.7"), netip.MustParseAddr("2001:db8::1")}
+	if !slices.Equal(list, want) {
+		t.Errorf("List() = %v, want %v", list, want)
+	}
+
+	// Refreshes replace the records
+	remote.set("198.51.100.1\n")
+	if err := tl.Refresh(true); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+	if got := tl.List(); !slices.Equal(got, []netip.Addr{netip.MustParseAddr("198.51.100.1")}) {
+		t.Errorf("List() after refreshing = %v, want [198.51.100.1]", got)
+	}
+}
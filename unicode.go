@@ -0,0 +1,82 @@
+package remotelist
+
+import (
+	"sort"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// unicodeFold returns `value` in NFC with Unicode case folding applied (see WithUnicodeFolding)
+func (rl *RemoteList) unicodeFold(value string) string {
+	value = norm.NFC.String(value)
+	// A Caser must not be shared between goroutines
+	if rl.foldLanguage == language.Und {
+		return cases.Fold().String(value)
+	}
+	return cases.Lower(rl.foldLanguage).String(value)
+}
+
+// setUnicodeFuncs sets the default functions that are not provided to ones that compare with rl.fold,
+// i.e. with Unicode case folding unless matching is case-sensitive (see WithUnicodeFolding)
+func (rl *RemoteList) setUnicodeFuncs() {
+	if rl.fnHas == nil {
+		rl.fnHas = func(records map[string]struct{}, term string) bool {
+			term = rl.fold(term)
+			for rec := range records {
+				if rl.fold(rec) == term {
+					return true
+				}
+			}
+			return false
+		}
+		if rl.lowercase || rl.sensitive {
+			rl.fnHas = func(records map[string]struct{}, term string) bool {
+				_, ok := records[rl.fold(term)]
+				return ok
+			}
+		}
+	}
+	if rl.fnHasPrefix == nil {
+		rl.fnHasPrefix = func(records map[string]struct{}, term string) bool {
+			term = rl.fold(term)
+			for rec := range records {
+				if strings.HasPrefix(rl.fold(rec), term) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if rl.fnHasSuffix == nil {
+		rl.fnHasSuffix = func(records map[string]struct{}, term string) bool {
+			term = rl.fold(term)
+			for rec := range records {
+				if strings.HasSuffix(rl.fold(rec), term) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	if rl.fnSearch == nil {
+		rl.fnSearch = func(records map[string]struct{}, term string) []string {
+			term = rl.fold(term)
+			res := []string{}
+			for rec := range records {
+				if strings.Contains(rl.fold(rec), term) {
+					res = append(res, rec)
+				}
+			}
+			sort.Strings(res)
+			return res
+		}
+	}
+	if rl.fnMatch == nil {
+		rl.fnMatch = func(record, term string) (m Match, ok bool) {
+			return CaseSensitiveMatchFunc(rl.fold(record), rl.fold(term))
+		}
+	}
+}
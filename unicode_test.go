@@ -0,0 +1,51 @@
+package remotelist
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestWithUnicodeFolding(t *testing.T) {
+	const (
+		cafeNFC = "caf\u00e9.example"
+		cafeNFD = "cafe\u0301.example"
+	)
+	records := []string{cafeNFC, "straße.example", "istanbul.example", "ısparta.example"}
+	tests := []struct {
+		name  string
+		opts  []Option
+		value string
+		want  bool
+	}{
+		{"NFD query", []Option{WithUnicodeFolding(language.Und)}, cafeNFD, true},
+		{"NFD query without folding", nil, cafeNFD, false},
+		{"NFD query with fast has", []Option{WithUnicodeFolding(language.Und), WithFastHas()}, "CAFÉ.EXAMPLE", true},
+		{"sharp s", []Option{WithUnicodeFolding(language.Und)}, "STRASSE.example", true},
+		{"sharp s without folding", nil, "STRASSE.example", false},
+		{"Turkish dotted I", []Option{WithUnicodeFolding(language.Turkish)}, "İSTANBUL.example", true},
+		{"Turkish dotless I", []Option{WithUnicodeFolding(language.Turkish)}, "ISPARTA.example", true},
+		{"Turkish I is not i", []Option{WithUnicodeFolding(language.Turkish)}, "ISTANBUL.example", false},
+		{"dotless I without Turkish rules", []Option{WithUnicodeFolding(language.Und)}, "ISPARTA.example", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rl, err := NewFromStrings(records, tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := rl.Has(tt.value); got != tt.want {
+				t.Errorf("Has(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	// Records are normalized as well, so decomposed records match composed queries
+	rl, err := NewFromStrings([]string{cafeNFD}, WithUnicodeFolding(language.Und))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rl.Has(cafeNFC) || len(rl.Search("CAFÉ")) != 1 {
+		t.Errorf("decomposed record not found by its composed form")
+	}
+}
@@ -0,0 +1,82 @@
+package remotelist
+
+import (
+	"fmt"
+	"io"
+)
+
+// A `ValidatorFunc` validates the records of a downloaded list before it replaces the local file.
+//
+// It returns an error to reject the list, e.g. because it's an error page that has been served with status 200.
+type ValidatorFunc func(recordCount int, records map[string]struct{}) error
+
+// MinRecords returns a ValidatorFunc that rejects lists with less than `n` records.
+func MinRecords(n int) ValidatorFunc {
+	return func(recordCount int, records map[string]struct{}) error {
+		if recordCount < n {
+			return fmt.Errorf("got %d records, expected at least %d", recordCount, n)
+		}
+		return nil
+	}
+}
+
+// validating starts parsing what is written to the returned pipe, which must be closed by the caller.
+// The returned function waits for all data to be parsed and runs the validator on the records.
+// Errors are wrapped in ErrValidation, parse errors are also returned to the writer of the pipe.
+func (rl *RemoteList) validating() (*io.PipeWriter, func() error) {
+	pr, pw := io.Pipe()
+	records := map[string]struct{}{}
+	done := make(chan error, 1)
+	go func() {
+		malformed := 0
+		var errRead error
+		values := func(yield func(string) bool) {
+			for value, err := range rl.split(pr, &malformed) {
+				if err != nil {
+					errRead = err
+					return
+				}
+				if !yield(value) {
+					return
+				}
+			}
+		}
+		rl.parse(values, records)
+		if errRead != nil {
+			errRead = fmt.Errorf("%w: %w", ErrValidation, errRead)
+		}
+		pr.CloseWithError(errRead)
+		done <- errRead
+	}()
+
+	return pw, func() error {
+		pw.Close()
+		if err := <-done; err != nil {
+			return err
+		}
+		var err error
+		rl.callback(func() { err = rl.fnValidate(len(records), records) })
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrValidation, err)
+		}
+		return nil
+	}
+}
+
+// maxShrink returns a ValidatorFunc that rejects lists that have more than `percent` percent fewer records than
+// the list that is currently loaded, not counting local overrides and added records. It accepts any list if
+// none has been loaded yet.
+func (rl *RemoteList) maxShrink(percent float64) ValidatorFunc {
+	return func(recordCount int, records map[string]struct{}) error {
+		rl.mu.RLock()
+		loaded, current := rl.loaded, rl.downloaded
+		rl.mu.RUnlock()
+		if !loaded || current == 0 {
+			return nil
+		}
+		if threshold := float64(current) * (1 - percent/100); float64(recordCount) < threshold {
+			return fmt.Errorf("got %d records, the current list has %d (shrank by more than %g%%)", recordCount, current, percent)
+		}
+		return nil
+	}
+}
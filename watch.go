@@ -0,0 +1,93 @@
+package remotelist
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// fileState identifies a version of the local file
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// localState returns the state of the local file
+func (rl *RemoteList) localState() (fileState, error) {
+	fileInfo, err := rl.storage.Stat(rl.fileLocal)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{modTime: fileInfo.ModTime(), size: fileInfo.Size()}, nil
+}
+
+// startWatch starts the goroutine that watches the local file if enabled with WithWatch
+func (rl *RemoteList) startWatch() {
+	if rl.watchInterval <= 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	if rl.closed {
+		cancel()
+		return
+	}
+	rl.watchCancel, rl.watchDone = cancel, done
+	go rl.watch(ctx, done)
+}
+
+// watch polls the local file every watchInterval and parses it again once it has been changed by someone else.
+// A change is only picked up once the file stayed the same for one interval, so rapid successive writes
+// cause a single reload. If the file can't be read or parsed, the current records are kept.
+func (rl *RemoteList) watch(ctx context.Context, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(rl.watchInterval)
+	defer ticker.Stop()
+	var pending, failed fileState
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		state, err := rl.localState()
+		rl.mu.RLock()
+		loaded, initialized := rl.loadedState, rl.loaded
+		rl.mu.RUnlock()
+		if err != nil || !initialized || state == loaded || state == failed {
+			pending = fileState{}
+			continue
+		}
+		if state != pending {
+			pending = state
+			continue
+		}
+
+		pending = fileState{}
+		rl.log(slog.LevelInfo, "local file changed, reloading list")
+		if err := rl.init(); err != nil {
+			failed = state
+			rl.log(slog.LevelWarn, "could not reload changed local file, keeping current records", "error", err)
+			continue
+		}
+		rl.mu.Lock()
+		rl.stale, rl.lastErr, rl.lastRefresh = false, nil, time.Now()
+		rl.mu.Unlock()
+	}
+}
+
+// stopWatch stops the goroutine that watches the local file (if any) and waits for it to exit
+func (rl *RemoteList) stopWatch() {
+	rl.mu.Lock()
+	cancel, done := rl.watchCancel, rl.watchDone
+	rl.watchCancel, rl.watchDone = nil, nil
+	rl.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,69 @@
+package remotelist
+
+import (
+	"bytes"
+	"compress/gzip"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedBuffer is a bytes.Buffer that can be written by the watcher while the test reads it
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatchKeepsRecordsOfBadFile(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	zw.Write([]byte(strings.Repeat("b.com\n", 1000)))
+	zw.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+		bad  []byte
+		good []byte
+	}{
+		{"line too long", []Option{WithMaxLineLength(64)}, []byte("b.com\n" + strings.Repeat("x", 100) + "\n"), []byte("b.com\n")},
+		{"partially written gzip", []Option{WithCompressedCache()}, compressed.Bytes()[:compressed.Len()/2], compressed.Bytes()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			remote := newTestRemote(t, "a.com\n")
+			logs := &lockedBuffer{}
+			opts := append([]Option{WithWatch(10 * time.Millisecond), WithLogger(slog.New(slog.NewTextHandler(logs, nil)))}, tt.opts...)
+			rl := newTestList(t, remote.URL, opts...)
+
+			if err := os.WriteFile(rl.fileLocal, tt.bad, 0644); err != nil {
+				t.Fatal(err)
+			}
+			eventually(t, func() bool { return strings.Contains(logs.String(), "could not reload changed local file") })
+			if got := rl.List(); len(got) != 1 || got[0] != "a.com" {
+				t.Errorf("List() = %q, want [a.com]", got)
+			}
+
+			// Once the file is complete, it is picked up
+			if err := os.WriteFile(rl.fileLocal, tt.good, 0644); err != nil {
+				t.Fatal(err)
+			}
+			eventually(t, func() bool { return rl.Has("b.com") && !rl.Has("a.com") })
+		})
+	}
+}